@@ -0,0 +1,272 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/mailer"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	// maxPasswordLength is the number of bytes bcrypt takes into account.
+	maxPasswordLength = 72
+
+	tokenPurposeEmailChange = "email_change"
+	emailChangeTokenTTL     = 24 * time.Hour
+)
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("Password must be at least %d characters long", minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("Password must be at most %d bytes long", maxPasswordLength)
+	}
+	return nil
+}
+
+// checkCurrentPassword responds with an error and returns false unless
+// password is the current password of the user.
+func checkCurrentPassword(rw http.ResponseWriter, user database.User, password string) bool {
+	err := auth.CheckPasswordHash(password, user.HashedPassword)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			respondWithError(rw, http.StatusForbidden, "Current password is incorrect", err)
+			return false
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return false
+	}
+	return true
+}
+
+// getAuthenticatedUser is like getAuthenticatedUserID but loads the user,
+// responding with an error if it cannot.
+func (ac *apiConfig) getAuthenticatedUser(rw http.ResponseWriter, req *http.Request) (database.User, bool) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return database.User{}, false
+	}
+	user, err := ac.dbQueries.FindUserById(req.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+			return database.User{}, false
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// handlerChangePassword replaces the password of the authenticated user
+// and signs them out everywhere by revoking all their refresh tokens. The
+// access token does not tell which session it belongs to, so the current
+// session has to log in again too once its access token expires.
+func (ac *apiConfig) handlerChangePassword(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if !checkCurrentPassword(rw, user, params.CurrentPassword) {
+		return
+	}
+	if err := validatePassword(params.NewPassword); err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	hashedPassword, err := auth.HashPassword(params.NewPassword)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		err := qtx.UpdateUserPassword(req.Context(), database.UpdateUserPasswordParams{
+			HashedPassword: hashedPassword,
+			ID:             user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return qtx.RevokeUserRefreshTokens(req.Context(), user.ID)
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to change password", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRequestEmailChange sends a confirmation token to the new address.
+// The email of the user only changes once the token is confirmed.
+func (ac *apiConfig) handlerRequestEmailChange(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		NewEmail string `json:"new_email"`
+		Password string `json:"password"`
+	}
+
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if !checkCurrentPassword(rw, user, params.Password) {
+		return
+	}
+	if !mailer.ValidAddress(params.NewEmail) {
+		respondWithError(rw, http.StatusBadRequest, "Invalid email", nil)
+		return
+	}
+	if params.NewEmail == user.Email {
+		respondWithError(rw, http.StatusBadRequest, "New email is the current email", nil)
+		return
+	}
+	_, err := ac.dbQueries.FindUserByEmail(req.Context(), params.NewEmail)
+	if err == nil {
+		respondWithError(rw, http.StatusConflict, "Email already taken", nil)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	token, err := ac.issueAccountToken(req.Context(), user.ID, tokenPurposeEmailChange, params.NewEmail, emailChangeTokenTTL)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to request email change", err)
+		return
+	}
+
+	err = ac.mailer.Send(req.Context(), mailer.Message{
+		To:      params.NewEmail,
+		Subject: "Confirm your new Chirpy email",
+		Body: fmt.Sprintf("Confirm this address for your Chirpy account by sending the following token to POST /api/users/me/email/confirm:\n\n%s\n\nThe token expires in %s.",
+			token, emailChangeTokenTTL),
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to send confirmation email", err)
+		return
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// handlerConfirmEmailChange applies the email change matching the token
+// sent to the new address. The token alone authenticates the request.
+func (ac *apiConfig) handlerConfirmEmailChange(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Token string `json:"token"`
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+
+	var user database.User
+	err := ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		token, err := qtx.ConsumeAccountToken(req.Context(), database.ConsumeAccountTokenParams{
+			TokenHash: auth.HashToken(params.Token),
+			Purpose:   tokenPurposeEmailChange,
+		})
+		if err != nil {
+			return err
+		}
+		user, err = qtx.UpdateUserEmail(req.Context(), database.UpdateUserEmailParams{
+			Email: token.Email,
+			ID:    token.UserID,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusBadRequest, "Invalid or expired token", err)
+			return
+		}
+		if isUniqueViolation(err) {
+			respondWithError(rw, http.StatusConflict, "Email already taken", err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to change email", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, newUser(user))
+}
+
+// handlerDeleteCurrentUser permanently deletes the authenticated user and
+// everything they own, including their uploaded media.
+func (ac *apiConfig) handlerDeleteCurrentUser(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Password string `json:"password"`
+	}
+
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if !checkCurrentPassword(rw, user, params.Password) {
+		return
+	}
+
+	var media []database.Medium
+	err := ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		var err error
+		media, err = qtx.ListMediaByUser(req.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+		_, err = qtx.DeleteUser(req.Context(), user.ID)
+		return err
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to delete account", err)
+		return
+	}
+
+	// The files are only removed once the rows referencing them are gone.
+	ctx := context.WithoutCancel(req.Context())
+	for _, medium := range media {
+		if err := ac.mediaStorage.Delete(ctx, medium.StorageKey); err != nil {
+			log.Printf("Failed to delete media %s of deleted user %s: %s\n", medium.StorageKey, user.ID, err)
+		}
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Blocking a user hides the chirps of each user from the other and keeps
+// the blocked user from interacting with the chirps of the blocker. Muting
+// a user only hides their chirps from the lists and timelines of the muter.
+
+type blockInfo struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// isBlockedBetween reports whether the viewer and the given user blocked
+// one another. Anonymous viewers are never blocked.
+func (ac *apiConfig) isBlockedBetween(ctx context.Context, viewerID uuid.NullUUID, userID uuid.UUID) (bool, error) {
+	if !viewerID.Valid || viewerID.UUID == userID {
+		return false, nil
+	}
+	return ac.dbQueries.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+		UserID:  viewerID.UUID,
+		OtherID: userID,
+	})
+}
+
+// findVisibleChirp is like FindPublishedChirpById but also reports the
+// chirp as missing if the viewer and its author blocked one another.
+func (ac *apiConfig) findVisibleChirp(ctx context.Context, viewerID uuid.NullUUID, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := ac.dbQueries.FindPublishedChirpById(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	blocked, err := ac.isBlockedBetween(ctx, viewerID, chirp.UserID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if blocked {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+// hiddenUserIDs returns the users whose chirps are left out of the lists
+// shown to the viewer.
+func (ac *apiConfig) hiddenUserIDs(ctx context.Context, viewerID uuid.NullUUID) (map[uuid.UUID]bool, error) {
+	hidden := map[uuid.UUID]bool{}
+	if !viewerID.Valid {
+		return hidden, nil
+	}
+	userIDs, err := ac.dbQueries.ListHiddenUserIDs(ctx, viewerID.UUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range userIDs {
+		hidden[userID] = true
+	}
+	return hidden, nil
+}
+
+// getOtherUserID returns the authenticated user and the user in the path,
+// responding with an error unless both exist and are different users.
+func (ac *apiConfig) getOtherUserID(rw http.ResponseWriter, req *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	otherID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if otherID == userID {
+		respondWithError(rw, http.StatusBadRequest, "Users cannot block or mute themselves", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	_, err = ac.dbQueries.FindUserById(req.Context(), otherID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User %s not found", otherID), err)
+			return uuid.Nil, uuid.Nil, false
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, otherID, true
+}
+
+// handlerBlockUser blocks the user in the path. Any follow between the two
+// users is removed. Blocking a user that is already blocked is a no-op.
+func (ac *apiConfig) handlerBlockUser(rw http.ResponseWriter, req *http.Request) {
+	userID, blockedID, ok := ac.getOtherUserID(rw, req)
+	if !ok {
+		return
+	}
+
+	err := ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		_, err := qtx.CreateBlock(req.Context(), database.CreateBlockParams{
+			BlockerID: userID,
+			BlockedID: blockedID,
+		})
+		if err != nil {
+			return err
+		}
+		return qtx.DeleteFollowsBetween(req.Context(), database.DeleteFollowsBetweenParams{
+			UserID:  userID,
+			OtherID: blockedID,
+		})
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to block user", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerUnblockUser(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	blockedID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	rowsAffected, err := ac.dbQueries.DeleteBlock(req.Context(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User %s is not blocked", blockedID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerMuteUser mutes the user in the path. Muting a user that is already
+// muted is a no-op.
+func (ac *apiConfig) handlerMuteUser(rw http.ResponseWriter, req *http.Request) {
+	userID, mutedID, ok := ac.getOtherUserID(rw, req)
+	if !ok {
+		return
+	}
+
+	_, err := ac.dbQueries.CreateMute(req.Context(), database.CreateMuteParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to mute user", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerUnmuteUser(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	mutedID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	rowsAffected, err := ac.dbQueries.DeleteMute(req.Context(), database.DeleteMuteParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User %s is not muted", mutedID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListBlocks lists the users blocked by the authenticated user, most
+// recent first by default.
+func (ac *apiConfig) handlerListBlocks(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var blocks []database.Block
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		blocks, err = ac.dbQueries.ListBlocksAsc(req.Context(), database.ListBlocksAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		blocks, err = ac.dbQueries.ListBlocksDesc(req.Context(), database.ListBlocksDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	blocks, links := paginate(page, blocks, func(block database.Block) pageCursor {
+		return pageCursor{CreatedAt: block.CreatedAt, ID: block.BlockedID}
+	})
+	response := make([]blockInfo, 0, len(blocks))
+	for _, block := range blocks {
+		response = append(response, blockInfo{UserID: block.BlockedID, CreatedAt: block.CreatedAt})
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerListMutes lists the users muted by the authenticated user, most
+// recent first by default.
+func (ac *apiConfig) handlerListMutes(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var mutes []database.Mute
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		mutes, err = ac.dbQueries.ListMutesAsc(req.Context(), database.ListMutesAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		mutes, err = ac.dbQueries.ListMutesDesc(req.Context(), database.ListMutesDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	mutes, links := paginate(page, mutes, func(mute database.Mute) pageCursor {
+		return pageCursor{CreatedAt: mute.CreatedAt, ID: mute.MutedID}
+	})
+	response := make([]blockInfo, 0, len(mutes))
+	for _, mute := range mutes {
+		response = append(response, blockInfo{UserID: mute.MutedID, CreatedAt: mute.CreatedAt})
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"chirpy/internal/auth"
+	"chirpy/internal/chirptext"
 	"chirpy/internal/database"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,17 +35,314 @@ func filterProfanity(msg string) string {
 	return getCleanedMsg(msg, profanityWords)
 }
 
+const maxChirpLength = 140
+
+// validateChirpBody checks the body written by a user and returns the text
+// to store for it.
+func validateChirpBody(body string) (string, error) {
+	if len(body) > maxChirpLength {
+		return "", errors.New("Chirp is too long")
+	}
+	return filterProfanity(body), nil
+}
+
+// indexChirpBody stores the hashtags and mentions found in the body of a
+// chirp so that it can be found through them. Mentions of handles that
+// belong to no user are left as plain text.
+func indexChirpBody(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	for _, tag := range chirptext.ExtractHashtags(chirp.Body) {
+		tagID, err := qtx.UpsertTag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = qtx.CreateChirpTag(ctx, database.CreateChirpTagParams{
+			ChirpID: chirp.ID,
+			TagID:   tagID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	mentions := chirptext.ExtractMentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+	handles := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		handles = append(handles, mention.Handle)
+	}
+	users, err := qtx.FindUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	userIDByHandle := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDByHandle[user.Handle.String] = user.ID
+	}
+	for _, mention := range mentions {
+		userID, ok := userIDByHandle[mention.Handle]
+		if !ok {
+			continue
+		}
+		err = qtx.CreateMention(ctx, database.CreateMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userID,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	chirpKindChirp   = "chirp"
+	chirpKindRechirp = "rechirp"
+	chirpKindQuote   = "quote"
+)
+
+const (
+	chirpStatusDraft     = "draft"
+	chirpStatusScheduled = "scheduled"
+	chirpStatusPublished = "published"
+)
+
 type chirpInfo struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserID     uuid.UUID  `json:"user_id"`
+	InReplyTo  *uuid.UUID `json:"in_reply_to"`
+	ReplyCount int64      `json:"reply_count"`
+	LikeCount  int64      `json:"like_count"`
+	LikedByMe  bool       `json:"liked_by_me"`
+	Kind       string     `json:"kind"`
+	RechirpOf  *uuid.UUID `json:"rechirp_of"`
+	// Original is the chirp shared by a rechirp or quote. It is null if that
+	// chirp has been deleted since or its author is hidden from the viewer.
+	Original *chirpInfo      `json:"original"`
+	Mentions []mentionEntity `json:"mentions"`
+	Edited   bool            `json:"edited"`
+	EditedAt *time.Time      `json:"edited_at"`
+	Media    []mediaInfo     `json:"media"`
+	// Status is either draft, scheduled or published. Only the author can
+	// see chirps that are not published yet.
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+	Poll      *pollInfo  `json:"poll"`
+	// Pinned is set on chirps their author pinned to their profile.
+	Pinned bool `json:"pinned"`
+	// DeletedAt is only set on chirps listed in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// mentionEntity locates a mention of a user in the body of a chirp. Start
+// and End are offsets in Unicode code points and cover the '@'.
+type mentionEntity struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+func newChirpInfo(chirp database.Chirp) chirpInfo {
+	info := chirpInfo{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		Kind:      chirp.Kind,
+		Mentions:  []mentionEntity{},
+		Media:     []mediaInfo{},
+		Status:    chirp.Status,
+	}
+	if chirp.InReplyTo.Valid {
+		info.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	if chirp.RechirpOf.Valid {
+		info.RechirpOf = &chirp.RechirpOf.UUID
+	}
+	if chirp.EditedAt.Valid {
+		info.Edited = true
+		info.EditedAt = &chirp.EditedAt.Time
+	}
+	if chirp.PublishAt.Valid {
+		info.PublishAt = &chirp.PublishAt.Time
+	}
+	return info
+}
+
+// buildChirpInfos converts chirps into their API representation, loading
+// the aggregated data shown alongside each chirp in batch. The viewer is
+// the authenticated user the chirps are shown to, if any.
+func (ac *apiConfig) buildChirpInfos(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp) ([]chirpInfo, error) {
+	infos, err := ac.decorateChirps(ctx, viewerID, chirps)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shared chirps are embedded a single level deep, so a quote of a quote
+	// only carries the ID of the innermost chirp.
+	originalIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			originalIDs = append(originalIDs, chirp.RechirpOf.UUID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return infos, nil
+	}
+	originals, err := ac.dbQueries.FindChirpsByIds(ctx, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+	originalInfos, err := ac.decorateChirps(ctx, viewerID, originals)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := ac.hiddenUserIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	embedOriginals(infos, originalInfos, hidden)
+	return infos, nil
+}
+
+// embedOriginals sets the original of the rechirps and quotes among infos.
+// Originals written by hidden users are left out, as if they were deleted,
+// so a rechirp by a third user does not reveal them to the viewer.
+func embedOriginals(infos []chirpInfo, originals []chirpInfo, hidden map[uuid.UUID]bool) {
+	originalByID := make(map[uuid.UUID]*chirpInfo, len(originals))
+	for i := range originals {
+		if hidden[originals[i].UserID] {
+			continue
+		}
+		originalByID[originals[i].ID] = &originals[i]
+	}
+	for i := range infos {
+		if infos[i].RechirpOf != nil {
+			infos[i].Original = originalByID[*infos[i].RechirpOf]
+		}
+	}
+}
+
+func (ac *apiConfig) decorateChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp) ([]chirpInfo, error) {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	replyCounts, err := ac.dbQueries.CountRepliesForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	replyCountByChirp := make(map[uuid.UUID]int64, len(replyCounts))
+	for _, count := range replyCounts {
+		replyCountByChirp[count.InReplyTo.UUID] = count.ReplyCount
+	}
+
+	likeCounts, err := ac.dbQueries.CountLikesForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	likeCountByChirp := make(map[uuid.UUID]int64, len(likeCounts))
+	for _, count := range likeCounts {
+		likeCountByChirp[count.ChirpID] = count.LikeCount
+	}
+
+	likedByViewer := map[uuid.UUID]bool{}
+	if viewerID.Valid {
+		likedChirpIDs, err := ac.dbQueries.ListLikedChirpIDs(ctx, database.ListLikedChirpIDsParams{
+			UserID:   viewerID.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, chirpID := range likedChirpIDs {
+			likedByViewer[chirpID] = true
+		}
+	}
+
+	mentions, err := ac.dbQueries.ListMentionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	mentionsByChirp := map[uuid.UUID][]mentionEntity{}
+	for _, mention := range mentions {
+		mentionsByChirp[mention.ChirpID] = append(mentionsByChirp[mention.ChirpID], mentionEntity{
+			UserID: mention.UserID,
+			Handle: mention.Handle.String,
+			Start:  mention.StartOffset,
+			End:    mention.EndOffset,
+		})
+	}
+
+	media, err := ac.dbQueries.ListMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	mediaByChirp := map[uuid.UUID][]mediaInfo{}
+	for _, medium := range media {
+		mediaByChirp[medium.ChirpID.UUID] = append(mediaByChirp[medium.ChirpID.UUID], ac.newMediaInfo(medium))
+	}
+
+	pollByChirp, err := ac.loadPolls(ctx, viewerID, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pinnedChirpIDs, err := ac.dbQueries.ListPinnedChirpIDs(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[uuid.UUID]bool, len(pinnedChirpIDs))
+	for _, chirpID := range pinnedChirpIDs {
+		pinned[chirpID] = true
+	}
+
+	infos := make([]chirpInfo, 0, len(chirps))
+	for _, chirp := range chirps {
+		info := newChirpInfo(chirp)
+		if chirpMentions, ok := mentionsByChirp[chirp.ID]; ok {
+			info.Mentions = chirpMentions
+		}
+		if chirpMedia, ok := mediaByChirp[chirp.ID]; ok {
+			info.Media = chirpMedia
+		}
+		info.Poll = pollByChirp[chirp.ID]
+		info.Pinned = pinned[chirp.ID]
+		info.ReplyCount = replyCountByChirp[chirp.ID]
+		info.LikeCount = likeCountByChirp[chirp.ID]
+		info.LikedByMe = likedByViewer[chirp.ID]
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (ac *apiConfig) buildChirpInfo(ctx context.Context, viewerID uuid.NullUUID, chirp database.Chirp) (chirpInfo, error) {
+	infos, err := ac.buildChirpInfos(ctx, viewerID, []database.Chirp{chirp})
+	if err != nil {
+		return chirpInfo{}, err
+	}
+	return infos[0], nil
 }
 
 func (ac *apiConfig) handlerCreateChirp(rw http.ResponseWriter, req *http.Request) {
 	type createChirpBody struct {
-		Body string `json:"body"`
+		Body      string      `json:"body"`
+		InReplyTo *uuid.UUID  `json:"in_reply_to"`
+		MediaIDs  []uuid.UUID `json:"media_ids"`
+		// Draft saves the chirp without publishing it. PublishAt schedules
+		// it to be published at a later time instead.
+		Draft     bool         `json:"draft"`
+		PublishAt *time.Time   `json:"publish_at"`
+		Poll      *pollRequest `json:"poll"`
 	}
 
 	token, err := auth.GetBearerToken(req.Header)
@@ -64,77 +364,244 @@ func (ac *apiConfig) handlerCreateChirp(rw http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	const maxChirpLength = 140
-	if len(params.Body) > maxChirpLength {
-		respondWithError(rw, http.StatusBadRequest, "Chirp is too long", nil)
+	body, err := validateChirpBody(params.Body)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	status, publishAt, err := parseChirpStatus(params.Draft, params.PublishAt)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	var pollLabels []string
+	if params.Poll != nil {
+		// Drafts are checked against the current time, as they may be
+		// published at any point.
+		pollStart := time.Now()
+		if publishAt.Valid {
+			pollStart = publishAt.Time
+		}
+		pollLabels, err = validatePoll(*params.Poll, pollStart)
+		if err != nil {
+			respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	if len(params.MediaIDs) > maxMediaPerChirp {
+		respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Too many media attachments. Must be at most %d", maxMediaPerChirp), nil)
 		return
 	}
 
-	chirp, err := ac.dbQueries.CreateChirp(req.Context(), database.CreateChirpParams{
-		Body:   filterProfanity(params.Body),
-		UserID: userID,
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := ac.findVisibleChirp(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, *params.InReplyTo)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Chirp %s being replied to not found", *params.InReplyTo), err)
+				return
+			}
+			respondWithError(rw, http.StatusInternalServerError, "Failed to create chirp", err)
+			return
+		}
+		// Replies to a plain rechirp belong to the conversation of the
+		// chirp it shares.
+		if parent.Kind == chirpKindRechirp && parent.RechirpOf.Valid {
+			parent.ID = parent.RechirpOf.UUID
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	var chirp database.Chirp
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		chirp, err = qtx.CreateChirp(req.Context(), database.CreateChirpParams{
+			Body:      body,
+			UserID:    userID,
+			InReplyTo: inReplyTo,
+			Status:    status,
+			PublishAt: publishAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := attachChirpMedia(req.Context(), qtx, chirp, params.MediaIDs); err != nil {
+			return err
+		}
+		if params.Poll != nil {
+			if err := createPoll(req.Context(), qtx, chirp, pollLabels, params.Poll.ClosesAt); err != nil {
+				return err
+			}
+		}
+		return indexChirpBody(req.Context(), qtx, chirp)
 	})
 	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			respondWithError(rw, http.StatusBadRequest, "Media not found or already attached to a chirp", err)
+			return
+		}
 		respondWithError(rw, http.StatusInternalServerError, "Failed to create chirp", err)
 		return
 	}
 
-	respondWithJSON(rw, http.StatusCreated, chirpInfo{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusCreated, response)
 }
 
 func (ac *apiConfig) handlerListAllChirps(rw http.ResponseWriter, req *http.Request) {
-
-	var chirps []database.Chirp
-	var err error
-
-	authorIDString := req.URL.Query().Get("author_id")
-	sortOrder := req.URL.Query().Get("sort")
-	if sortOrder == "" {
-		sortOrder = "asc"
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
 	}
-	if sortOrder != "asc" && sortOrder != "desc" {
-		respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid sort order '%s'. Must be either 'asc' or 'desc'", sortOrder), nil)
+	page, err := parsePageRequest(req.URL.Query(), "asc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
+	authorID := uuid.NullUUID{}
+	authorIDString := req.URL.Query().Get("author_id")
 	if authorIDString != "" {
-		authorID, err := uuid.Parse(authorIDString)
+		authorID.UUID, err = uuid.Parse(authorIDString)
 		if err != nil {
 			respondWithError(rw, http.StatusBadRequest, "Invalid author ID", err)
 			return
 		}
-		chirps, err = ac.dbQueries.ListAllChirpsForUser(req.Context(), authorID)
+		authorID.Valid = true
+	}
+	// pinned_first puts the chirps pinned by the author at the top of the
+	// listing, counting against the size of its first page. They are left
+	// out of the rest of the listing so that they are not shown twice.
+	pinnedFirst := false
+	if pinnedFirstString := req.URL.Query().Get("pinned_first"); pinnedFirstString != "" {
+		pinnedFirst, err = strconv.ParseBool(pinnedFirstString)
+		if err != nil {
+			respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid pinned_first '%s'. Must be a boolean", pinnedFirstString), err)
+			return
+		}
+		if pinnedFirst && !authorID.Valid {
+			respondWithError(rw, http.StatusBadRequest, "pinned_first requires an author ID", nil)
+			return
+		}
+	}
+
+	var pinnedChirps []database.Chirp
+	if pinnedFirst {
+		hidden, err := ac.hiddenUserIDs(req.Context(), viewerID)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+			return
+		}
+		if !hidden[authorID.UUID] {
+			pinnedChirps, err = ac.dbQueries.ListPinnedChirps(req.Context(), authorID.UUID)
+			if err != nil {
+				respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+				return
+			}
+		}
+		// Pinned chirps that do not fit on the first page keep their place
+		// in the listing.
+		if len(pinnedChirps) > int(page.Limit) {
+			pinnedChirps = pinnedChirps[:page.Limit]
+		}
+	}
+	pinnedIDs := make([]uuid.UUID, 0, len(pinnedChirps))
+	for _, chirp := range pinnedChirps {
+		pinnedIDs = append(pinnedIDs, chirp.ID)
+	}
+	listChirps := func(page pageRequest) ([]database.Chirp, error) {
+		cursorCreatedAt, cursorID := page.cursorArgs()
+		if page.ascending() {
+			return ac.dbQueries.ListChirpsAsc(req.Context(), database.ListChirpsAscParams{
+				AuthorID:        authorID,
+				ViewerID:        viewerID,
+				ExcludedIds:     pinnedIDs,
+				CursorCreatedAt: cursorCreatedAt,
+				CursorID:        cursorID,
+				PageSize:        page.fetchLimit(),
+			})
+		}
+		return ac.dbQueries.ListChirpsDesc(req.Context(), database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			ViewerID:        viewerID,
+			ExcludedIds:     pinnedIDs,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+
+	// The top page holds the pinned chirps followed by the first topLimit
+	// other chirps. Pages are aligned on it when paging backward, and
+	// reaching it serves the whole top page again.
+	topLimit := page.Limit - int32(len(pinnedChirps))
+	atTop := page.Cursor == nil && !page.FromStart
+	var chirps []database.Chirp
+	var links pageLinks
+	if page.Backward && len(pinnedChirps) > 0 {
+		backwardPage := page
+		backwardPage.Limit = page.Limit + topLimit
+		chirps, err = listChirps(backwardPage)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+			return
+		}
+		if len(chirps) <= int(topLimit) {
+			atTop = true
+		} else {
+			// The chirps are fetched closest to the cursor first.
+			chirps = chirps[:min(int(page.Limit), len(chirps)-int(topLimit))]
+			slices.Reverse(chirps)
+			links.Prev = chirpCursor(chirps[0]).encode()
+			links.Next = chirpCursor(chirps[len(chirps)-1]).encode()
+		}
+	} else if !atTop {
+		chirps, err = listChirps(page)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+			return
+		}
+		chirps, links = paginate(page, chirps, chirpCursor)
+		if page.FromStart && len(pinnedChirps) > 0 && len(chirps) > 0 {
+			links.Prev = chirpCursor(chirps[0]).encode()
+		}
+	}
+	if atTop {
+		topPage := pageRequest{Limit: topLimit, Desc: page.Desc}
+		chirps, err = listChirps(topPage)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+			return
+		}
+		if topLimit == 0 {
+			// The pinned chirps fill the top page by themselves.
+			links = pageLinks{NextFromStart: len(chirps) > 0}
+			chirps = nil
+		} else {
+			chirps, links = paginate(topPage, chirps, chirpCursor)
+		}
 	} else {
-		chirps, err = ac.dbQueries.ListAllChirps(req.Context())
+		pinnedChirps = nil
 	}
+
+	response, err := ac.buildChirpInfos(req.Context(), viewerID, append(pinnedChirps, chirps...))
 	if err != nil {
 		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
 		return
 	}
 
-	if sortOrder == "desc" {
-		slices.Reverse(chirps)
-	}
-
-	response := make([]chirpInfo, 0, len(chirps))
-	for _, chirp := range chirps {
-		response = append(response, chirpInfo{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		})
-	}
+	setPaginationLinks(rw, req, links)
 	respondWithJSON(rw, http.StatusOK, response)
 }
 
+func chirpCursor(chirp database.Chirp) pageCursor {
+	return pageCursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
+}
+
 func (ac *apiConfig) GetChirpById(rw http.ResponseWriter, req *http.Request) {
 	chirpIDString := req.PathValue("id")
 	if chirpIDString == "" {
@@ -147,6 +614,12 @@ func (ac *apiConfig) GetChirpById(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
 	chirp, err := ac.dbQueries.FindChirpById(req.Context(), chirpID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -156,14 +629,26 @@ func (ac *apiConfig) GetChirpById(rw http.ResponseWriter, req *http.Request) {
 		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirp", err)
 		return
 	}
+	if chirp.Status != chirpStatusPublished && (!viewerID.Valid || viewerID.UUID != chirp.UserID) {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), nil)
+		return
+	}
+	blocked, err := ac.isBlockedBetween(req.Context(), viewerID, chirp.UserID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirp", err)
+		return
+	}
+	if blocked {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), nil)
+		return
+	}
 
-	respondWithJSON(rw, http.StatusOK, chirpInfo{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	response, err := ac.buildChirpInfo(req.Context(), viewerID, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirp", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, response)
 }
 
 func (ac *apiConfig) handlerDeleteChirp(rw http.ResponseWriter, req *http.Request) {
@@ -204,7 +689,7 @@ func (ac *apiConfig) handlerDeleteChirp(rw http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	err = ac.dbQueries.DeleteChirpById(req.Context(), chirp.ID)
+	err = ac.dbQueries.SoftDeleteChirpById(req.Context(), chirp.ID)
 	if err != nil {
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
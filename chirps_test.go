@@ -0,0 +1,212 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEmbedOriginals(t *testing.T) {
+	author := uuid.New()
+	blocker := uuid.New()
+	original := chirpInfo{ID: uuid.New(), UserID: author}
+	blockerOriginal := chirpInfo{ID: uuid.New(), UserID: blocker}
+	missingID := uuid.New()
+
+	tests := []struct {
+		name      string
+		rechirpOf *uuid.UUID
+		hidden    map[uuid.UUID]bool
+		want      *uuid.UUID
+	}{
+		{
+			name:      "Visible original",
+			rechirpOf: &original.ID,
+			hidden:    map[uuid.UUID]bool{},
+			want:      &original.ID,
+		},
+		{
+			name:      "Original by hidden user",
+			rechirpOf: &blockerOriginal.ID,
+			hidden:    map[uuid.UUID]bool{blocker: true},
+			want:      nil,
+		},
+		{
+			name:      "Deleted original",
+			rechirpOf: &missingID,
+			hidden:    map[uuid.UUID]bool{},
+			want:      nil,
+		},
+		{
+			name:      "Not a rechirp",
+			rechirpOf: nil,
+			hidden:    map[uuid.UUID]bool{blocker: true},
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infos := []chirpInfo{{ID: uuid.New(), UserID: uuid.New(), RechirpOf: tt.rechirpOf}}
+			embedOriginals(infos, []chirpInfo{original, blockerOriginal}, tt.hidden)
+			got := infos[0].Original
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("embedOriginals() expected no original, actual = %s", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != *tt.want {
+				t.Errorf("embedOriginals() expected original = %s, actual = %v", *tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListChirpsPinnedFirst(t *testing.T) {
+	authorID := uuid.New()
+	start := time.Now().Add(-time.Hour).UTC()
+	var listing []database.Chirp
+	for i, body := range []string{"R1", "P1", "R2", "R3", "P2", "R4", "R5"} {
+		listing = append(listing, database.Chirp{
+			ID:        uuid.New(),
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: start,
+			Body:      body,
+			UserID:    authorID,
+			Kind:      "chirp",
+			Status:    chirpStatusPublished,
+		})
+	}
+	pinned := []database.Chirp{listing[4], listing[1]}
+
+	// listChirps mimics ListChirpsAsc and ListChirpsDesc over listing.
+	listChirps := func(desc bool) func(args []driver.NamedValue) fakeResult {
+		return func(args []driver.NamedValue) fakeResult {
+			excluded := args[2].Value.([]uuid.UUID)
+			cursorCreatedAt := args[3].Value.(sql.NullTime)
+			cursorID := args[4].Value.(uuid.NullUUID)
+			pageSize := int(args[5].Value.(int32))
+			rows := slices.Clone(listing)
+			if desc {
+				slices.Reverse(rows)
+			}
+			models := []any{}
+			for _, chirp := range rows {
+				if slices.Contains(excluded, chirp.ID) {
+					continue
+				}
+				if cursorCreatedAt.Valid {
+					cmp := chirp.CreatedAt.Compare(cursorCreatedAt.Time)
+					if cmp == 0 {
+						cmp = slices.Compare(chirp.ID[:], cursorID.UUID[:])
+					}
+					if (desc && cmp >= 0) || (!desc && cmp <= 0) {
+						continue
+					}
+				}
+				if len(models) < pageSize {
+					models = append(models, chirp)
+				}
+			}
+			return modelResult(models...)
+		}
+	}
+	linkRegexp := map[string]*regexp.Regexp{
+		"next": regexp.MustCompile(`<([^>]+)>; rel="next"`),
+		"prev": regexp.MustCompile(`<([^>]+)>; rel="prev"`),
+	}
+
+	tests := []struct {
+		name  string
+		limit string
+		want  [][]string
+	}{
+		{
+			name:  "Pinned chirps and others on the top page",
+			limit: "3",
+			want:  [][]string{{"P2", "P1", "R1"}, {"R2", "R3", "R4"}, {"R5"}},
+		},
+		{
+			name:  "Pinned chirps fill the top page",
+			limit: "2",
+			want:  [][]string{{"P2", "P1"}, {"R1", "R2"}, {"R3", "R4"}, {"R5"}},
+		},
+		{
+			name:  "Pinned chirps do not fit on the top page",
+			limit: "1",
+			want:  [][]string{{"P2"}, {"R1"}, {"P1"}, {"R2"}, {"R3"}, {"R4"}, {"R5"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			fake.on("ListPinnedChirps", modelResult(pinned[0], pinned[1]))
+			fake.on("ListChirpsAsc", fakeResult{fn: listChirps(false)})
+			fake.on("ListChirpsDesc", fakeResult{fn: listChirps(true)})
+			for _, name := range []string{"FindChirpsByIds", "CountRepliesForChirps", "CountLikesForChirps", "ListMentionsForChirps", "ListMediaForChirps", "ListPollsForChirps", "ListPinnedChirpIDs"} {
+				fake.on(name, fakeResult{})
+			}
+			ac := &apiConfig{db: db, dbQueries: database.New(db)}
+
+			// getPage returns the bodies of the chirps on the page and the
+			// URL of the page in the given direction.
+			getPage := func(url, rel string) ([]string, string) {
+				t.Helper()
+				rec := httptest.NewRecorder()
+				ac.handlerListAllChirps(rec, httptest.NewRequest(http.MethodGet, url, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET %s status = %d: %s", url, rec.Code, rec.Body)
+				}
+				var chirps []chirpInfo
+				if err := json.NewDecoder(rec.Body).Decode(&chirps); err != nil {
+					t.Fatalf("Failed to decode chirps: %s", err)
+				}
+				bodies := []string{}
+				for _, chirp := range chirps {
+					bodies = append(bodies, chirp.Body)
+				}
+				match := linkRegexp[rel].FindStringSubmatch(rec.Header().Get("Link"))
+				if match == nil {
+					return bodies, ""
+				}
+				return bodies, match[1]
+			}
+
+			url := "/api/chirps?pinned_first=true&author_id=" + authorID.String() + "&limit=" + tt.limit
+			var forward [][]string
+			var lastURL string
+			for url != "" && len(forward) <= len(tt.want) {
+				var bodies []string
+				lastURL = url
+				bodies, url = getPage(url, "next")
+				forward = append(forward, bodies)
+			}
+			if !slices.EqualFunc(forward, tt.want, slices.Equal) {
+				t.Fatalf("forward pages = %v, want %v", forward, tt.want)
+			}
+
+			// Walking back from the last page gives the same pages.
+			_, url = getPage(lastURL, "prev")
+			var backward [][]string
+			for url != "" && len(backward) <= len(tt.want) {
+				var bodies []string
+				bodies, url = getPage(url, "prev")
+				backward = append([][]string{bodies}, backward...)
+			}
+			if want := tt.want[:len(tt.want)-1]; !slices.EqualFunc(backward, want, slices.Equal) {
+				t.Fatalf("backward pages = %v, want %v", backward, want)
+			}
+		})
+	}
+}
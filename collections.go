@@ -0,0 +1,350 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxCollectionNameLength = 50
+
+type collectionInfo struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func newCollectionInfo(collection database.Collection) collectionInfo {
+	return collectionInfo{
+		ID:        collection.ID,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+		Name:      collection.Name,
+	}
+}
+
+func validateCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("Collection name not specified")
+	}
+	if len(name) > maxCollectionNameLength {
+		return "", fmt.Errorf("Collection name must be at most %d characters long", maxCollectionNameLength)
+	}
+	return name, nil
+}
+
+// findOwnCollection returns the collection with the given ID if it belongs
+// to the user. Collections are private, so the collections of other users
+// are reported as missing rather than forbidden.
+func (ac *apiConfig) findOwnCollection(ctx context.Context, userID, collectionID uuid.UUID) (database.Collection, error) {
+	collection, err := ac.dbQueries.FindCollectionById(ctx, collectionID)
+	if err != nil {
+		return database.Collection{}, err
+	}
+	if collection.UserID != userID {
+		return database.Collection{}, sql.ErrNoRows
+	}
+	return collection, nil
+}
+
+// getOwnCollection resolves the collection in the path for the
+// authenticated user, responding with an error if it cannot.
+func (ac *apiConfig) getOwnCollection(rw http.ResponseWriter, req *http.Request) (database.Collection, bool) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return database.Collection{}, false
+	}
+	collectionID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid collection ID", err)
+		return database.Collection{}, false
+	}
+	collection, err := ac.findOwnCollection(req.Context(), userID, collectionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Collection %s not found", collectionID), err)
+			return database.Collection{}, false
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return database.Collection{}, false
+	}
+	return collection, true
+}
+
+func (ac *apiConfig) handlerCreateCollection(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Name string `json:"name"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	name, err := validateCollectionName(params.Name)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	collection, err := ac.dbQueries.CreateCollection(req.Context(), database.CreateCollectionParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(rw, http.StatusConflict, fmt.Sprintf("Collection '%s' already exists", name), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to create collection", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusCreated, newCollectionInfo(collection))
+}
+
+func (ac *apiConfig) handlerListCollections(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "asc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var collections []database.Collection
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		collections, err = ac.dbQueries.ListCollectionsAsc(req.Context(), database.ListCollectionsAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		collections, err = ac.dbQueries.ListCollectionsDesc(req.Context(), database.ListCollectionsDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get collections", err)
+		return
+	}
+
+	collections, links := paginate(page, collections, func(c database.Collection) pageCursor {
+		return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	response := make([]collectionInfo, 0, len(collections))
+	for _, collection := range collections {
+		response = append(response, newCollectionInfo(collection))
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+func (ac *apiConfig) handlerGetCollection(rw http.ResponseWriter, req *http.Request) {
+	collection, ok := ac.getOwnCollection(rw, req)
+	if !ok {
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, newCollectionInfo(collection))
+}
+
+func (ac *apiConfig) handlerRenameCollection(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Name string `json:"name"`
+	}
+
+	collection, ok := ac.getOwnCollection(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	name, err := validateCollectionName(params.Name)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	collection, err = ac.dbQueries.RenameCollection(req.Context(), database.RenameCollectionParams{
+		Name: name,
+		ID:   collection.ID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(rw, http.StatusConflict, fmt.Sprintf("Collection '%s' already exists", name), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to rename collection", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, newCollectionInfo(collection))
+}
+
+func (ac *apiConfig) handlerDeleteCollection(rw http.ResponseWriter, req *http.Request) {
+	collection, ok := ac.getOwnCollection(rw, req)
+	if !ok {
+		return
+	}
+	if err := ac.dbQueries.DeleteCollection(req.Context(), collection.ID); err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to delete collection", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerAddChirpToCollection(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		ChirpID uuid.UUID `json:"chirp_id"`
+	}
+
+	collection, ok := ac.getOwnCollection(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+
+	_, err := ac.findVisibleChirp(req.Context(), uuid.NullUUID{UUID: collection.UserID, Valid: true}, params.ChirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", params.ChirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	_, err = ac.dbQueries.AddChirpToCollection(req.Context(), database.AddChirpToCollectionParams{
+		CollectionID: collection.ID,
+		ChirpID:      params.ChirpID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerRemoveChirpFromCollection(rw http.ResponseWriter, req *http.Request) {
+	collection, ok := ac.getOwnCollection(rw, req)
+	if !ok {
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "chirp_id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	rowsAffected, err := ac.dbQueries.RemoveChirpFromCollection(req.Context(), database.RemoveChirpFromCollectionParams{
+		CollectionID: collection.ID,
+		ChirpID:      chirpID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s is not in the collection", chirpID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListCollectionChirps lists the chirps in a collection, most
+// recently added first by default.
+func (ac *apiConfig) handlerListCollectionChirps(rw http.ResponseWriter, req *http.Request) {
+	collection, ok := ac.getOwnCollection(rw, req)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	type savedChirp struct {
+		chirp  database.Chirp
+		cursor pageCursor
+	}
+	var saved []savedChirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		rows, err := ac.dbQueries.ListCollectionChirpsAsc(req.Context(), database.ListCollectionChirpsAscParams{
+			CollectionID:    collection.ID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+			return
+		}
+		for _, row := range rows {
+			saved = append(saved, savedChirp{row.Chirp, pageCursor{CreatedAt: row.AddedAt, ID: row.Chirp.ID}})
+		}
+	} else {
+		rows, err := ac.dbQueries.ListCollectionChirpsDesc(req.Context(), database.ListCollectionChirpsDescParams{
+			CollectionID:    collection.ID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+			return
+		}
+		for _, row := range rows {
+			saved = append(saved, savedChirp{row.Chirp, pageCursor{CreatedAt: row.AddedAt, ID: row.Chirp.ID}})
+		}
+	}
+
+	saved, links := paginate(page, saved, func(s savedChirp) pageCursor { return s.cursor })
+	chirps := make([]database.Chirp, 0, len(saved))
+	for _, s := range saved {
+		chirps = append(chirps, s.chirp)
+	}
+	response, err := ac.buildChirpInfos(req.Context(), uuid.NullUUID{UUID: collection.UserID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
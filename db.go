@@ -0,0 +1,35 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// errForbidden can be returned from a transaction when the authenticated
+// user turns out not to be allowed to perform the change.
+var errForbidden = errors.New("forbidden")
+
+// withTx runs fn with queries bound to a new transaction, which is
+// committed if fn succeeds and rolled back otherwise.
+func (ac *apiConfig) withTx(ctx context.Context, fn func(qtx *database.Queries) error) error {
+	tx, err := ac.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(ac.dbQueries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether err was caused by a row conflicting
+// with a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
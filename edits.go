@@ -0,0 +1,168 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var errChirpNotEditable = errors.New("chirp cannot be edited")
+
+// handlerEditChirp replaces the body of a chirp owned by the authenticated
+// user, keeping the previous body as a revision.
+func (ac *apiConfig) handlerEditChirp(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Body string `json:"body"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	body, err := validateChirpBody(params.Body)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirp database.Chirp
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		chirp, err = qtx.FindChirpByIdForUpdate(req.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != userID {
+			return errForbidden
+		}
+		if chirp.Kind == chirpKindRechirp {
+			return errChirpNotEditable
+		}
+		if chirp.Body == body {
+			return nil
+		}
+
+		// Drafts and scheduled chirps have not been seen by anyone yet, so
+		// their previous bodies are not kept.
+		if chirp.Status == chirpStatusPublished {
+			// The revision keeps the time its body was first published.
+			publishedAt := chirp.CreatedAt
+			if chirp.EditedAt.Valid {
+				publishedAt = chirp.EditedAt.Time
+			}
+			err = qtx.CreateChirpRevision(req.Context(), database.CreateChirpRevisionParams{
+				ChirpID:   chirp.ID,
+				Body:      chirp.Body,
+				CreatedAt: publishedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		chirp, err = qtx.UpdateChirpBody(req.Context(), database.UpdateChirpBodyParams{
+			Body: body,
+			ID:   chirp.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.DeleteChirpTags(req.Context(), chirp.ID); err != nil {
+			return err
+		}
+		if err := qtx.DeleteChirpMentions(req.Context(), chirp.ID); err != nil {
+			return err
+		}
+		return indexChirpBody(req.Context(), qtx, chirp)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+		case errors.Is(err, errForbidden):
+			respondWithError(rw, http.StatusForbidden, "Access Forbiden", nil)
+		case errors.Is(err, errChirpNotEditable):
+			respondWithError(rw, http.StatusBadRequest, "Rechirps cannot be edited", nil)
+		default:
+			respondWithError(rw, http.StatusInternalServerError, "Failed to edit chirp", err)
+		}
+		return
+	}
+
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerChirpHistory lists every version of the body of a chirp, oldest
+// first. The last entry is the current body and has no replaced_at.
+func (ac *apiConfig) handlerChirpHistory(rw http.ResponseWriter, req *http.Request) {
+	type chirpVersion struct {
+		Body       string     `json:"body"`
+		CreatedAt  time.Time  `json:"created_at"`
+		ReplacedAt *time.Time `json:"replaced_at"`
+	}
+
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	chirp, err := ac.findVisibleChirp(req.Context(), viewerID, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirp", err)
+		return
+	}
+	revisions, err := ac.dbQueries.ListChirpRevisions(req.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirp history", err)
+		return
+	}
+
+	response := make([]chirpVersion, 0, len(revisions)+1)
+	for _, revision := range revisions {
+		response = append(response, chirpVersion{
+			Body:       revision.Body,
+			CreatedAt:  revision.CreatedAt,
+			ReplacedAt: &revision.ReplacedAt,
+		})
+	}
+	current := chirpVersion{Body: chirp.Body, CreatedAt: chirp.CreatedAt}
+	if chirp.EditedAt.Valid {
+		current.CreatedAt = chirp.EditedAt.Time
+	}
+	response = append(response, current)
+	respondWithJSON(rw, http.StatusOK, response)
+}
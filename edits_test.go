@@ -0,0 +1,78 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChirpHistoryVisibility(t *testing.T) {
+	const tokenSecret = "secret"
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Body:      "Hello",
+		UserID:    uuid.New(),
+		Kind:      "chirp",
+		Status:    "published",
+	}
+
+	tests := []struct {
+		name       string
+		viewerID   uuid.UUID
+		blocked    bool
+		wantStatus int
+	}{
+		{
+			name:       "Anonymous viewer",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Unrelated viewer",
+			viewerID:   uuid.New(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Blocked viewer",
+			viewerID:   uuid.New(),
+			blocked:    true,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			fake.on("FindPublishedChirpById", modelResult(chirp))
+			fake.on("IsBlockedBetween", scalarResult(tc.blocked))
+			fake.on("ListChirpRevisions", fakeResult{})
+			ac := &apiConfig{db: db, dbQueries: database.New(db), tokenSecret: tokenSecret}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/chirps/{id}/history", ac.handlerChirpHistory)
+			req := httptest.NewRequest(http.MethodGet, "/api/chirps/"+chirp.ID.String()+"/history", nil)
+			if tc.viewerID != uuid.Nil {
+				token, err := auth.MakeJWT(tc.viewerID, tokenSecret, time.Hour)
+				if err != nil {
+					t.Fatalf("MakeJWT() error = %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.wantStatus, rec.Body)
+			}
+			if tc.blocked && fake.called("ListChirpRevisions") {
+				t.Errorf("revisions were loaded for a blocked viewer")
+			}
+		})
+	}
+}
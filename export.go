@@ -0,0 +1,214 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type exportProfile struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Handle        *string   `json:"handle"`
+	DisplayName   *string   `json:"display_name"`
+	Bio           *string   `json:"bio"`
+	AvatarURL     *string   `json:"avatar_url"`
+}
+
+type exportChirp struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	Kind      string     `json:"kind"`
+	Status    string     `json:"status"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	RechirpOf *uuid.UUID `json:"rechirp_of"`
+	EditedAt  *time.Time `json:"edited_at"`
+	PublishAt *time.Time `json:"publish_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+// exportSession describes a session from its latest refresh token, without
+// the token itself.
+type exportSession struct {
+	ID         uuid.UUID  `json:"id"`
+	StartedAt  time.Time  `json:"started_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+}
+
+func nullUUIDPtr(value uuid.NullUUID) *uuid.UUID {
+	if !value.Valid {
+		return nil
+	}
+	return &value.UUID
+}
+
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+// csvUUID and csvTime format optional values as CSV fields, leaving the
+// field empty when there is no value.
+func csvUUID(value *uuid.UUID) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func csvTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339)
+}
+
+// handlerExportCurrentUser responds with a zip archive of the data of the
+// authenticated user: their profile, their chirps, including drafts and
+// deleted ones, and their sessions. Lists are provided both as JSON and CSV.
+func (ac *apiConfig) handlerExportCurrentUser(rw http.ResponseWriter, req *http.Request) {
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+	chirps, err := ac.dbQueries.ListAllUserChirps(req.Context(), user.ID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to export chirps", err)
+		return
+	}
+	refreshTokens, err := ac.dbQueries.ListUserSessions(req.Context(), user.ID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to export sessions", err)
+		return
+	}
+
+	profile := exportProfile{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		IsChirpyRed:   user.IsChirpyRed,
+		Handle:        nullStringPtr(user.Handle),
+		DisplayName:   nullStringPtr(user.DisplayName),
+		Bio:           nullStringPtr(user.Bio),
+		AvatarURL:     nullStringPtr(user.AvatarUrl),
+	}
+
+	exportedChirps := make([]exportChirp, 0, len(chirps))
+	chirpRows := [][]string{{"id", "created_at", "updated_at", "body", "kind", "status", "in_reply_to", "rechirp_of", "edited_at", "publish_at", "deleted_at"}}
+	for _, chirp := range chirps {
+		c := exportChirp{
+			ID:        chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+			UpdatedAt: chirp.UpdatedAt,
+			Body:      chirp.Body,
+			Kind:      chirp.Kind,
+			Status:    chirp.Status,
+			InReplyTo: nullUUIDPtr(chirp.InReplyTo),
+			RechirpOf: nullUUIDPtr(chirp.RechirpOf),
+			EditedAt:  nullTimePtr(chirp.EditedAt),
+			PublishAt: nullTimePtr(chirp.PublishAt),
+			DeletedAt: nullTimePtr(chirp.DeletedAt),
+		}
+		exportedChirps = append(exportedChirps, c)
+		chirpRows = append(chirpRows, []string{
+			c.ID.String(), csvTime(&c.CreatedAt), csvTime(&c.UpdatedAt), c.Body, c.Kind, c.Status,
+			csvUUID(c.InReplyTo), csvUUID(c.RechirpOf), csvTime(c.EditedAt), csvTime(c.PublishAt), csvTime(c.DeletedAt),
+		})
+	}
+
+	sessions := make([]exportSession, 0, len(refreshTokens))
+	sessionRows := [][]string{{"id", "started_at", "last_used_at", "expires_at", "revoked_at", "user_agent", "ip_address"}}
+	for _, refreshToken := range refreshTokens {
+		s := exportSession{
+			ID:         refreshToken.SessionID,
+			StartedAt:  refreshToken.SessionStartedAt,
+			LastUsedAt: nullTimePtr(refreshToken.LastUsedAt),
+			ExpiresAt:  refreshToken.ExpiresAt,
+			RevokedAt:  nullTimePtr(refreshToken.RevokedAt),
+			UserAgent:  refreshToken.UserAgent,
+			IPAddress:  refreshToken.IpAddress,
+		}
+		sessions = append(sessions, s)
+		sessionRows = append(sessionRows, []string{
+			s.ID.String(), csvTime(&s.StartedAt), csvTime(s.LastUsedAt), csvTime(&s.ExpiresAt), csvTime(s.RevokedAt), s.UserAgent, s.IPAddress,
+		})
+	}
+
+	// The archive is built in memory so that errors can still be reported
+	// before anything is written to the response.
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name  string
+		write func(w *bytes.Buffer) error
+	}{
+		{"profile.json", jsonFile(profile)},
+		{"chirps.json", jsonFile(exportedChirps)},
+		{"chirps.csv", csvFile(chirpRows)},
+		{"sessions.json", jsonFile(sessions)},
+		{"sessions.csv", csvFile(sessionRows)},
+	}
+	for _, file := range files {
+		var content bytes.Buffer
+		if err := file.write(&content); err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to export data", err)
+			return
+		}
+		w, err := archive.Create(file.name)
+		if err == nil {
+			_, err = w.Write(content.Bytes())
+		}
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to export data", err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to export data", err)
+		return
+	}
+
+	filename := fmt.Sprintf("chirpy-export-%s.zip", time.Now().UTC().Format("2006-01-02"))
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	rw.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(buf.Bytes())
+}
+
+func jsonFile(value any) func(w *bytes.Buffer) error {
+	return func(w *bytes.Buffer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+}
+
+func csvFile(rows [][]string) func(w *bytes.Buffer) error {
+	return func(w *bytes.Buffer) error {
+		writer := csv.NewWriter(w)
+		return writer.WriteAll(rows)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a database/sql connector that answers the generated queries
+// with canned results, looked up by the name in their "-- name:" comment,
+// so that handlers can be tested without a database.
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	calls   []string
+}
+
+// fakeResult is what a query returns. rows are also counted as the rows
+// affected by :exec and :execrows queries. If fn is set, it computes the
+// result from the arguments of each call instead.
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+	fn      func(args []driver.NamedValue) fakeResult
+}
+
+var queryNameRegexp = regexp.MustCompile(`-- name: (\w+)`)
+
+func newFakeDB(t *testing.T) (*fakeDB, *sql.DB) {
+	t.Helper()
+	fake := &fakeDB{results: map[string]fakeResult{}}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return fake, db
+}
+
+// on sets the result of the named query.
+func (f *fakeDB) on(name string, result fakeResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[name] = result
+}
+
+// called reports whether the named query was run.
+func (f *fakeDB) called(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, call := range f.calls {
+		if call == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeDB) result(query string, args []driver.NamedValue) (fakeResult, error) {
+	match := queryNameRegexp.FindStringSubmatch(query)
+	if match == nil {
+		return fakeResult{}, fmt.Errorf("query without a name: %q", query)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, match[1])
+	result, ok := f.results[match[1]]
+	if !ok {
+		return fakeResult{}, fmt.Errorf("unexpected query %s", match[1])
+	}
+	if result.fn != nil {
+		result = result.fn(args)
+	}
+	return result, result.err
+}
+
+// modelRow turns a generated model into a row holding its fields in order.
+func modelRow(model any) []driver.Value {
+	v := reflect.ValueOf(model)
+	row := make([]driver.Value, v.NumField())
+	for i := range row {
+		field := v.Field(i).Interface()
+		if valuer, ok := field.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				panic(err)
+			}
+			field = value
+		}
+		row[i] = field
+	}
+	return row
+}
+
+// modelResult returns the given models as the rows of a result.
+func modelResult(models ...any) fakeResult {
+	result := fakeResult{}
+	for _, model := range models {
+		row := modelRow(model)
+		if result.columns == nil {
+			result.columns = make([]string, len(row))
+			for i := range row {
+				result.columns[i] = fmt.Sprintf("column%d", i)
+			}
+		}
+		result.rows = append(result.rows, row)
+	}
+	return result
+}
+
+// scalarResult returns a single row holding a single value.
+func scalarResult(value driver.Value) fakeResult {
+	return fakeResult{columns: []string{"value"}, rows: [][]driver.Value{{value}}}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{db: f}
+}
+
+type fakeDriver struct {
+	db *fakeDB
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+
+func (c fakeConn) Close() error { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.db.result(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.db.result(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(result.rows)), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type followInfo struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (ac *apiConfig) handlerFollowUser(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	followeeID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	if followeeID == userID {
+		respondWithError(rw, http.StatusBadRequest, "Users cannot follow themselves", nil)
+		return
+	}
+
+	_, err = ac.dbQueries.FindUserById(req.Context(), followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User %s not found", followeeID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	blocked, err := ac.isBlockedBetween(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, followeeID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if blocked {
+		respondWithError(rw, http.StatusForbidden, "Cannot follow a blocked user", nil)
+		return
+	}
+
+	_, err = ac.dbQueries.CreateFollow(req.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerUnfollowUser(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	followeeID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	rowsAffected, err := ac.dbQueries.DeleteFollow(req.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Not following user %s", followeeID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerListFollowers(rw http.ResponseWriter, req *http.Request) {
+	ac.listFollows(rw, req, true)
+}
+
+func (ac *apiConfig) handlerListFollowing(rw http.ResponseWriter, req *http.Request) {
+	ac.listFollows(rw, req, false)
+}
+
+// listFollows lists either the followers of the user in the path or the
+// users they follow, most recent relationships first by default.
+func (ac *apiConfig) listFollows(rw http.ResponseWriter, req *http.Request, followers bool) {
+	userID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	_, err = ac.dbQueries.FindUserById(req.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User %s not found", userID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	var follows []database.Follow
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	switch {
+	case followers && page.ascending():
+		follows, err = ac.dbQueries.ListFollowersAsc(req.Context(), database.ListFollowersAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	case followers:
+		follows, err = ac.dbQueries.ListFollowersDesc(req.Context(), database.ListFollowersDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	case page.ascending():
+		follows, err = ac.dbQueries.ListFolloweesAsc(req.Context(), database.ListFolloweesAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	default:
+		follows, err = ac.dbQueries.ListFolloweesDesc(req.Context(), database.ListFolloweesDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	otherUserID := func(follow database.Follow) uuid.UUID {
+		if followers {
+			return follow.FollowerID
+		}
+		return follow.FolloweeID
+	}
+	follows, links := paginate(page, follows, func(follow database.Follow) pageCursor {
+		return pageCursor{CreatedAt: follow.CreatedAt, ID: otherUserID(follow)}
+	})
+	setPaginationLinks(rw, req, links)
+
+	response := make([]followInfo, 0, len(follows))
+	for _, follow := range follows {
+		response = append(response, followInfo{
+			UserID:     otherUserID(follow),
+			FollowedAt: follow.CreatedAt,
+		})
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+func (ac *apiConfig) handlerTimeline(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirps []database.Chirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		chirps, err = ac.dbQueries.ListTimelineAsc(req.Context(), database.ListTimelineAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		chirps, err = ac.dbQueries.ListTimelineDesc(req.Context(), database.ListTimelineDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get timeline", err)
+		return
+	}
+
+	chirps, links := paginate(page, chirps, chirpCursor)
+	response, err := ac.buildChirpInfos(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get timeline", err)
+		return
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
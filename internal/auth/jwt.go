@@ -9,10 +9,49 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	accessTokenIssuer = "chirpy"
+	// mfaChallengeIssuer sets MFA challenge tokens apart so that they cannot
+	// be used as access tokens.
+	mfaChallengeIssuer = "chirpy-mfa"
+)
+
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return makeJWT(accessTokenIssuer, userID, "", tokenSecret, expiresIn)
+}
+
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	userID, _, err := validateJWT(accessTokenIssuer, tokenString, tokenSecret)
+	return userID, err
+}
+
+// MakeMFAChallengeJWT returns a token proving that the user passed the
+// first step of a login, to be exchanged along with a second factor for an
+// access token. The challenge ID identifies the server-side state of the
+// challenge, which limits how many times the token can be tried.
+func MakeMFAChallengeJWT(userID, challengeID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return makeJWT(mfaChallengeIssuer, userID, challengeID.String(), tokenSecret, expiresIn)
+}
+
+// ValidateMFAChallengeJWT returns the user and the challenge ID of an MFA
+// challenge token.
+func ValidateMFAChallengeJWT(tokenString, tokenSecret string) (uuid.UUID, uuid.UUID, error) {
+	userID, tokenID, err := validateJWT(mfaChallengeIssuer, tokenString, tokenSecret)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	challengeID, err := uuid.Parse(tokenID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return userID, challengeID, nil
+}
+
+func makeJWT(issuer string, userID uuid.UUID, tokenID string, tokenSecret string, expiresIn time.Duration) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
+		Issuer:    issuer,
 		Subject:   userID.String(),
+		ID:        tokenID,
 		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
 		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 	})
@@ -23,23 +62,30 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return signedToken, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
+// validateJWT returns the user and the ID of a token from the issuer. The
+// ID is empty unless the token has one.
+func validateJWT(issuer, tokenString, tokenSecret string) (uuid.UUID, string, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
 		return []byte(tokenSecret), nil
-	})
+	}, jwt.WithIssuer(issuer), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
 	_, err = token.Claims.GetExpirationTime()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
 
 	subject, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
+	}
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, "", err
 	}
-	return uuid.Parse(subject)
+	return userID, claims.ID, nil
 }
 
 func MakeRefreshToken() (string, error) {
@@ -79,3 +79,35 @@ func TestJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestMFAChallengeJWT(t *testing.T) {
+	tokenKey := "random-key"
+	userID := uuid.New()
+	challengeID := uuid.New()
+
+	challenge, err := MakeMFAChallengeJWT(userID, challengeID, tokenKey, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeMFAChallengeJWT() error = %v", err)
+	}
+	got, gotChallengeID, err := ValidateMFAChallengeJWT(challenge, tokenKey)
+	if err != nil {
+		t.Fatalf("ValidateMFAChallengeJWT() error = %v", err)
+	}
+	if got != userID {
+		t.Fatalf("ValidateMFAChallengeJWT() %v != %v", userID, got)
+	}
+	if gotChallengeID != challengeID {
+		t.Fatalf("ValidateMFAChallengeJWT() challenge %v != %v", challengeID, gotChallengeID)
+	}
+
+	if _, err := ValidateJWT(challenge, tokenKey); err == nil {
+		t.Fatalf("ValidateJWT() accepted an MFA challenge token")
+	}
+	access, err := MakeJWT(userID, tokenKey, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+	if _, _, err := ValidateMFAChallengeJWT(access, tokenKey); err == nil {
+		t.Fatalf("ValidateMFAChallengeJWT() accepted an access token")
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "testing"
+
+func TestMakeToken(t *testing.T) {
+	token1, err := MakeToken()
+	if err != nil {
+		t.Fatalf("Failed to make token: %s", err)
+	}
+	token2, err := MakeToken()
+	if err != nil {
+		t.Fatalf("Failed to make token: %s", err)
+	}
+	if len(token1) != 64 {
+		t.Fatalf("MakeToken() length expected = 64, actual = %d", len(token1))
+	}
+	if token1 == token2 {
+		t.Fatalf("Expected tokens to differ: %s", token1)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := HashToken("abc"); got != want {
+		t.Fatalf("HashToken() expected = %s, actual = %s", want, got)
+	}
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters, as defined by RFC 6238. They are the defaults assumed
+// by authenticator apps.
+const (
+	TOTPPeriod = 30 * time.Second
+	TOTPDigits = 6
+	// totpSkew is the number of periods a code is still accepted before or
+	// after its own, to allow for clock drift.
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MakeTOTPSecret returns a random 160 bit secret, base32 encoded as
+// authenticator apps expect it.
+func MakeTOTPSecret() (string, error) {
+	data := make([]byte, 20)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(data), nil
+}
+
+// TOTPURI returns the otpauth:// URI used to enroll the secret in an
+// authenticator app, usually shown as a QR code.
+func TOTPURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCounter returns the time step t falls in.
+func TOTPCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// MakeTOTPCode returns the code for the secret at time t.
+func MakeTOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(TOTPCounter(t)), TOTPDigits), nil
+}
+
+// ValidateTOTPCode checks a code against the secret at time t and returns
+// the time step it was generated for. Callers should reject codes for a
+// step at or before the last one used, so that a code cannot be replayed.
+func ValidateTOTPCode(secret, code string, t time.Time) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := TOTPCounter(t)
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		expected := hotp(key, uint64(counter), TOTPDigits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	return totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+}
+
+// hotp computes an HOTP value as defined by RFC 4226, which TOTP applies
+// to the current time step.
+func hotp(key []byte, counter uint64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for range digits {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// MakeRecoveryCodes returns n random single use codes, formatted as
+// "xxxxx-xxxxx", to be used when the authenticator app is unavailable.
+// They are short enough to be guessed offline from a fast hash, so they are
+// stored with HashRecoveryCode like passwords.
+func MakeRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for range n {
+		data := make([]byte, 7)
+		_, err := rand.Read(data)
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(data))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode ignores the case, spaces and dashes of a recovery
+// code typed by a user.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// HashRecoveryCode returns the bcrypt hash of a recovery code, after
+// NormalizeRecoveryCode.
+func HashRecoveryCode(code string) (string, error) {
+	return HashPassword(NormalizeRecoveryCode(code))
+}
+
+// CheckRecoveryCode reports whether code matches a hash returned by
+// HashRecoveryCode. Codes issued before they were hashed with bcrypt are
+// stored as a HashToken digest, which is still accepted.
+func CheckRecoveryCode(code, hash string) bool {
+	code = NormalizeRecoveryCode(code)
+	if !strings.HasPrefix(hash, "$2") {
+		return subtle.ConstantTimeCompare([]byte(HashToken(code)), []byte(hash)) == 1
+	}
+	return CheckPasswordHash(code, hash) == nil
+}
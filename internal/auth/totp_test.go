@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTP(t *testing.T) {
+	// Test values from RFC 4226, appendix D.
+	key := []byte("12345678901234567890")
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range want {
+		if got := hotp(key, uint64(counter), 6); got != code {
+			t.Errorf("hotp(counter = %d) = %v, expected = %v", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPRFC6238(t *testing.T) {
+	// SHA-1 test values from RFC 6238, appendix B.
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		counter := TOTPCounter(time.Unix(tt.unix, 0))
+		if got := hotp(key, uint64(counter), 8); got != tt.code {
+			t.Errorf("TOTP at %d = %v, expected = %v", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+	code, err := MakeTOTPCode(secret, now)
+	if err != nil {
+		t.Fatalf("MakeTOTPCode() error = %v", err)
+	}
+	if code != "050471" {
+		t.Fatalf("MakeTOTPCode() = %v, expected = 050471", code)
+	}
+
+	tests := []struct {
+		name        string
+		secret      string
+		code        string
+		at          time.Time
+		wantOK      bool
+		wantCounter int64
+	}{
+		{"Current period", secret, code, now, true, TOTPCounter(now)},
+		{"Previous period", secret, code, now.Add(TOTPPeriod), true, TOTPCounter(now)},
+		{"Next period", secret, code, now.Add(-TOTPPeriod), true, TOTPCounter(now)},
+		{"Too late", secret, code, now.Add(2 * TOTPPeriod), false, 0},
+		{"Wrong code", secret, "123456", now, false, 0},
+		{"Wrong length", secret, code[:5], now, false, 0},
+		{"Lowercase secret", strings.ToLower(secret), code, now, true, TOTPCounter(now)},
+		{"Invalid secret", "not base32!", code, now, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := ValidateTOTPCode(tt.secret, tt.code, tt.at)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTPCode() ok = %v, expected = %v", ok, tt.wantOK)
+			}
+			if ok && counter != tt.wantCounter {
+				t.Fatalf("ValidateTOTPCode() counter = %v, expected = %v", counter, tt.wantCounter)
+			}
+		})
+	}
+}
+
+func TestMakeTOTPSecret(t *testing.T) {
+	secret, err := MakeTOTPSecret()
+	if err != nil {
+		t.Fatalf("MakeTOTPSecret() error = %v", err)
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("decodeTOTPSecret() error = %v", err)
+	}
+	if len(key) != 20 {
+		t.Fatalf("MakeTOTPSecret() key length = %d, expected = 20", len(key))
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Chirpy", "alice@example.com", "JBSWY3DPEHPK3PXP")
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("TOTPURI() = %v is not a valid URL: %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Fatalf("TOTPURI() = %v, expected an otpauth://totp/ URI", uri)
+	}
+	if parsed.Path != "/Chirpy:alice@example.com" {
+		t.Fatalf("TOTPURI() label = %v, expected = /Chirpy:alice@example.com", parsed.Path)
+	}
+	query := parsed.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "Chirpy" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Fatalf("TOTPURI() query = %v", query)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := MakeRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("MakeRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("MakeRecoveryCodes() returned %d codes, expected = 10", len(codes))
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Fatalf("Recovery code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Fatalf("Recovery code %q is repeated", code)
+		}
+		seen[code] = true
+		typed := " " + strings.ToUpper(strings.Replace(code, "-", " - ", 1))
+		if NormalizeRecoveryCode(typed) != NormalizeRecoveryCode(code) {
+			t.Fatalf("NormalizeRecoveryCode(%q) = %q, expected = %q", typed, NormalizeRecoveryCode(typed), NormalizeRecoveryCode(code))
+		}
+	}
+}
+
+func TestCheckRecoveryCode(t *testing.T) {
+	codes, err := MakeRecoveryCodes(2)
+	if err != nil {
+		t.Fatalf("MakeRecoveryCodes() error = %v", err)
+	}
+	hash, err := HashRecoveryCode(codes[0])
+	if err != nil {
+		t.Fatalf("HashRecoveryCode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		hash string
+		want bool
+	}{
+		{
+			name: "Matching code",
+			code: codes[0],
+			hash: hash,
+			want: true,
+		},
+		{
+			name: "Code typed differently",
+			code: strings.ToUpper(strings.Replace(codes[0], "-", " ", 1)),
+			hash: hash,
+			want: true,
+		},
+		{
+			name: "Other code",
+			code: codes[1],
+			hash: hash,
+			want: false,
+		},
+		{
+			name: "Legacy digest",
+			code: codes[0],
+			hash: HashToken(NormalizeRecoveryCode(codes[0])),
+			want: true,
+		},
+		{
+			name: "Other code against a legacy digest",
+			code: codes[1],
+			hash: HashToken(NormalizeRecoveryCode(codes[0])),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckRecoveryCode(tt.code, tt.hash); got != tt.want {
+				t.Errorf("CheckRecoveryCode() = %v, expected = %v", got, tt.want)
+			}
+		})
+	}
+	if hash == HashToken(NormalizeRecoveryCode(codes[0])) {
+		t.Errorf("HashRecoveryCode() returned an unsalted digest")
+	}
+}
@@ -0,0 +1,67 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 100
+
+// ExtractHashtags returns the distinct hashtags found in body, lowercased
+// and without the leading '#', in order of first appearance. A hashtag must
+// start at the beginning of the body or after a character that cannot be
+// part of a word, and must contain at least one letter.
+func ExtractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]struct{}{}
+	for _, token := range scanTokens(body, '#') {
+		tag := strings.ToLower(token.Text)
+		if len([]rune(tag)) > maxHashtagLength || !strings.ContainsFunc(tag, unicode.IsLetter) {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NormalizeHashtag turns user input such as "#Golang" into the form
+// returned by ExtractHashtags.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// token is a word introduced by a sigil such as '#'. Start and End are
+// offsets in runes into the scanned text, covering the sigil.
+type token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func scanTokens(text string, sigil rune) []token {
+	tokens := []token{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != sigil || (i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == sigil)) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		if end == i+1 {
+			continue
+		}
+		tokens = append(tokens, token{Text: string(runes[i+1 : end]), Start: i, End: end})
+		i = end - 1
+	}
+	return tokens
+}
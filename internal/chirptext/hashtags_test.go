@@ -0,0 +1,78 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No hashtags",
+			body: "Just a regular chirp",
+			want: []string{},
+		},
+		{
+			name: "Single hashtag",
+			body: "Learning #golang today",
+			want: []string{"golang"},
+		},
+		{
+			name: "Lowercased and deduplicated",
+			body: "#Go is great, #GO is fast, #go",
+			want: []string{"go"},
+		},
+		{
+			name: "Order of first appearance",
+			body: "#b then #a then #b",
+			want: []string{"b", "a"},
+		},
+		{
+			name: "Punctuation ends hashtag",
+			body: "Shipping #release-2024!",
+			want: []string{"release"},
+		},
+		{
+			name: "Inside word is ignored",
+			body: "issue#123 and foo#bar",
+			want: []string{},
+		},
+		{
+			name: "Digits only is ignored",
+			body: "#1 fan of #2024 #year2024",
+			want: []string{"year2024"},
+		},
+		{
+			name: "Unicode letters",
+			body: "Bonjour #café",
+			want: []string{"café"},
+		},
+		{
+			name: "Lone and doubled sigils",
+			body: "# ## ##tag",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractHashtags(tt.body)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ExtractHashtags() expected = %v, actual = %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	if got := NormalizeHashtag("#GoLang"); got != "golang" {
+		t.Fatalf("NormalizeHashtag() expected = golang, actual = %v", got)
+	}
+	if got := NormalizeHashtag("golang"); got != "golang" {
+		t.Fatalf("NormalizeHashtag() expected = golang, actual = %v", got)
+	}
+}
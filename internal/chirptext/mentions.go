@@ -0,0 +1,50 @@
+package chirptext
+
+import (
+	"strings"
+)
+
+const maxHandleLength = 30
+
+// Mention is an @handle found in a chirp body. Start and End are offsets
+// in runes (Unicode code points) into the body and cover the '@'.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ExtractMentions returns every well-formed @handle in body in order of
+// appearance. Handles are normalized with NormalizeHandle. Whether the
+// handles belong to existing users is left to the caller.
+func ExtractMentions(body string) []Mention {
+	mentions := []Mention{}
+	for _, token := range scanTokens(body, '@') {
+		handle := NormalizeHandle(token.Text)
+		if !IsValidHandle(handle) {
+			continue
+		}
+		mentions = append(mentions, Mention{Handle: handle, Start: token.Start, End: token.End})
+	}
+	return mentions
+}
+
+// NormalizeHandle lowercases a handle and strips a leading '@', so that
+// handles are unique regardless of the case they are written in.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// IsValidHandle reports whether handle, once normalized, only contains
+// lowercase ASCII letters, digits and underscores and is not too long.
+func IsValidHandle(handle string) bool {
+	if handle == "" || len(handle) > maxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
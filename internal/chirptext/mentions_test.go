@@ -0,0 +1,83 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Mention
+	}{
+		{
+			name: "No mentions",
+			body: "Nobody here",
+			want: []Mention{},
+		},
+		{
+			name: "Single mention",
+			body: "Hello @alice!",
+			want: []Mention{{Handle: "alice", Start: 6, End: 12}},
+		},
+		{
+			name: "Normalized handle",
+			body: "@Bob_99 hi",
+			want: []Mention{{Handle: "bob_99", Start: 0, End: 7}},
+		},
+		{
+			name: "Repeated mentions are kept",
+			body: "@a and @a",
+			want: []Mention{{Handle: "a", Start: 0, End: 2}, {Handle: "a", Start: 7, End: 9}},
+		},
+		{
+			name: "Email address is ignored",
+			body: "mail me at alice@example.com",
+			want: []Mention{},
+		},
+		{
+			name: "Offsets count runes",
+			body: "café @zoë @zo",
+			want: []Mention{{Handle: "zo", Start: 10, End: 13}},
+		},
+		{
+			name: "Handle too long",
+			body: "@" + "abcdefghijklmnopqrstuvwxyz01234",
+			want: []Mention{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractMentions(tt.body)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ExtractMentions() expected = %v, actual = %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsValidHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{handle: "alice", want: true},
+		{handle: "bob_99", want: true},
+		{handle: "", want: false},
+		{handle: "Alice", want: false},
+		{handle: "al-ice", want: false},
+		{handle: "zoë", want: false},
+		{handle: "abcdefghijklmnopqrstuvwxyz0123", want: true},
+		{handle: "abcdefghijklmnopqrstuvwxyz01234", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			if got := IsValidHandle(tt.handle); got != tt.want {
+				t.Fatalf("IsValidHandle(%q) expected = %v, actual = %v", tt.handle, tt.want, got)
+			}
+		})
+	}
+}
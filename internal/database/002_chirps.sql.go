@@ -7,29 +7,79 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const countRepliesForChirps = `-- name: CountRepliesForChirps :many
+SELECT in_reply_to, COUNT(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY($1::uuid[]) AND status = 'published' AND deleted_at IS NULL
+GROUP BY in_reply_to
+`
+
+type CountRepliesForChirpsRow struct {
+	InReplyTo  uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) CountRepliesForChirps(ctx context.Context, chirpIds []uuid.UUID) ([]CountRepliesForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepliesForChirps, chirpIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesForChirpsRow
+	for rows.Next() {
+		var i CountRepliesForChirpsRow
+		if err := rows.Scan(
+			&i.InReplyTo,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, status, publish_at)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	InReplyTo uuid.NullUUID
+	Status    string
+	PublishAt sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.Status,
+		arg.PublishAt,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,21 +87,67 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const deleteChirpById = `-- name: DeleteChirpById :exec
-DELETE FROM chirps WHERE id = $1
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, rechirp_of)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, rechirp_of) WHERE kind = 'rechirp' AND deleted_at IS NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at
 `
 
-func (q *Queries) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirpById, id)
-	return err
+type CreateRechirpParams struct {
+	Body      string
+	UserID    uuid.UUID
+	Kind      string
+	RechirpOf uuid.NullUUID
+}
+
+// Creating a plain rechirp twice is a no-op that returns no rows.
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp,
+		arg.Body,
+		arg.UserID,
+		arg.Kind,
+		arg.RechirpOf,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
 const findChirpById = `-- name: FindChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) FindChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -63,16 +159,473 @@ func (q *Queries) FindChirpById(ctx context.Context, id uuid.UUID) (Chirp, error
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const findChirpByIdForUpdate = `-- name: FindChirpByIdForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+`
+
+func (q *Queries) FindChirpByIdForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, findChirpByIdForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const findChirpsByIds = `-- name: FindChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+`
+
+func (q *Queries) FindChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, findChirpsByIds, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findPublishedChirpById = `-- name: FindPublishedChirpById :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps WHERE id = $1 AND status = 'published' AND deleted_at IS NULL
+`
+
+func (q *Queries) FindPublishedChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, findPublishedChirpById, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const listAllChirps = `-- name: ListAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+const listAllUserChirps = `-- name: ListAllUserChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps WHERE user_id = $1 ORDER BY created_at, id
+`
+
+// Includes drafts, scheduled chirps and chirps in the trash.
+func (q *Queries) ListAllUserChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listAllUserChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpAncestors = `-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to, depth) AS (
+    SELECT parent.id, parent.in_reply_to, 1
+    FROM chirps AS child
+    JOIN chirps AS parent ON parent.id = child.in_reply_to
+    WHERE child.id = $1
+    UNION ALL
+    SELECT chirps.id, chirps.in_reply_to, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.in_reply_to
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.kind, chirps.rechirp_of, chirps.edited_at, chirps.status, chirps.publish_at, chirps.deleted_at FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE chirps.deleted_at IS NULL
+ORDER BY ancestors.depth DESC
+`
+
+func (q *Queries) ListChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpDescendants = `-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants (id, depth) AS (
+    SELECT chirps.id, 1
+    FROM chirps
+    WHERE chirps.in_reply_to = $1 AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+    FROM chirps
+    JOIN descendants ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < $2::int AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.kind, chirps.rechirp_of, chirps.edited_at, chirps.status, chirps.publish_at, chirps.deleted_at FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+ORDER BY chirps.created_at, chirps.id
+LIMIT $3
+`
+
+type ListChirpDescendantsParams struct {
+	ChirpID   uuid.UUID
+	MaxDepth  int32
+	MaxChirps int32
+}
+
+func (q *Queries) ListChirpDescendants(ctx context.Context, arg ListChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpDescendants, arg.ChirpID, arg.MaxDepth, arg.MaxChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps
+WHERE status = 'published' AND deleted_at IS NULL
+    AND ($1::uuid IS NULL OR user_id = $1)
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2)
+            OR (blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = chirps.user_id)
+    AND id <> ALL(COALESCE($3::uuid[], '{}'))
+    AND ($4::timestamptz IS NULL OR (created_at, id) > ($4, $5::uuid))
+ORDER BY created_at, id
+LIMIT $6
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	ExcludedIds     []uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+// Chirps of users the viewer blocked, muted or was blocked by are left out,
+// as well as the excluded chirps.
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.ExcludedIds,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps
+WHERE status = 'published' AND deleted_at IS NULL
+    AND ($1::uuid IS NULL OR user_id = $1)
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2)
+            OR (blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = chirps.user_id)
+    AND id <> ALL(COALESCE($3::uuid[], '{}'))
+    AND ($4::timestamptz IS NULL OR (created_at, id) < ($4, $5::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	ExcludedIds     []uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.ExcludedIds,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueChirpsForUpdate = `-- name: ListDueChirpsForUpdate :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.kind, chirps.rechirp_of, chirps.edited_at, chirps.status, chirps.publish_at, chirps.deleted_at, polls.closes_at AS poll_closes_at FROM chirps
+LEFT JOIN polls ON polls.chirp_id = chirps.id
+WHERE chirps.status = 'scheduled' AND chirps.publish_at <= NOW() AND chirps.deleted_at IS NULL
+ORDER BY chirps.publish_at
+LIMIT $1
+FOR UPDATE OF chirps SKIP LOCKED
+`
+
+type ListDueChirpsForUpdateRow struct {
+	Chirp        Chirp
+	PollClosesAt sql.NullTime
+}
+
+// Rows locked by a concurrent run, possibly on another server instance, are
+// skipped, so every chirp is handled exactly once.
+func (q *Queries) ListDueChirpsForUpdate(ctx context.Context, maxChirps int32) ([]ListDueChirpsForUpdateRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDueChirpsForUpdate, maxChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDueChirpsForUpdateRow
+	for rows.Next() {
+		var i ListDueChirpsForUpdateRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.Kind,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.EditedAt,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.DeletedAt,
+			&i.PollClosesAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserChirpsByStatusAsc = `-- name: ListUserChirpsByStatusAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps
+WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL
+    AND ($3::timestamptz IS NULL OR (created_at, id) > ($3, $4::uuid))
+ORDER BY created_at, id
+LIMIT $5
 `
 
-func (q *Queries) ListAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, listAllChirps)
+type ListUserChirpsByStatusAscParams struct {
+	UserID          uuid.UUID
+	Status          string
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListUserChirpsByStatusAsc(ctx context.Context, arg ListUserChirpsByStatusAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listUserChirpsByStatusAsc,
+		arg.UserID,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -86,6 +639,13 @@ func (q *Queries) ListAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -100,12 +660,30 @@ func (q *Queries) ListAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const listAllChirpsForUser = `-- name: ListAllChirpsForUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at
+const listUserChirpsByStatusDesc = `-- name: ListUserChirpsByStatusDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at FROM chirps
+WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL
+    AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
-func (q *Queries) ListAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, listAllChirpsForUser, userID)
+type ListUserChirpsByStatusDescParams struct {
+	UserID          uuid.UUID
+	Status          string
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListUserChirpsByStatusDesc(ctx context.Context, arg ListUserChirpsByStatusDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listUserChirpsByStatusDesc,
+		arg.UserID,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +697,13 @@ func (q *Queries) ListAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.EditedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -132,3 +717,128 @@ func (q *Queries) ListAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([
 	}
 	return items, nil
 }
+
+const publishChirp = `-- name: PublishChirp :one
+UPDATE chirps SET status = 'published', created_at = NOW(), updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at
+`
+
+// Publishing moves the chirp to the top of the listings.
+func (q *Queries) PublishChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const publishChirps = `-- name: PublishChirps :execrows
+UPDATE chirps SET status = 'published', created_at = NOW(), updated_at = NOW()
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) PublishChirps(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, publishChirps, ids)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteChirpById = `-- name: SoftDeleteChirpById :exec
+UPDATE chirps SET deleted_at = NOW()
+WHERE (id = $1 OR (rechirp_of = $1 AND kind = 'rechirp')) AND deleted_at IS NULL
+`
+
+// Plain rechirps are moved to the trash along with the chirp they share.
+func (q *Queries) SoftDeleteChirpById(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirpById, id)
+	return err
+}
+
+const unscheduleChirps = `-- name: UnscheduleChirps :execrows
+UPDATE chirps SET status = 'draft', publish_at = NULL, updated_at = NOW()
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) UnscheduleChirps(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unscheduleChirps, ids)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, edited_at = CASE WHEN status = 'published' THEN NOW() ELSE edited_at END, updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+// Only changes to published chirps count as edits.
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateChirpSchedule = `-- name: UpdateChirpSchedule :one
+UPDATE chirps SET status = $1, publish_at = $2, updated_at = NOW() WHERE id = $3 RETURNING id, created_at, updated_at, body, user_id, in_reply_to, kind, rechirp_of, edited_at, status, publish_at, deleted_at
+`
+
+type UpdateChirpScheduleParams struct {
+	Status    string
+	PublishAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateChirpSchedule(ctx context.Context, arg UpdateChirpScheduleParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpSchedule, arg.Status, arg.PublishAt, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.EditedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,65 +15,359 @@ import (
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens(
-    token,
+    token_hash,
     created_at,
     updated_at,
     user_id,
-    expires_at
+    expires_at,
+    session_id,
+    user_agent,
+    ip_address
 )
 VALUES(
     $1,
     NOW(),
     NOW(),
     $2,
-    $3
+    $3,
+    $4,
+    $5,
+    $6
 )
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, session_id, user_agent, ip_address, last_used_at, session_started_at, replaced_by, reuse_detected_at
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
+	TokenHash string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	SessionID uuid.UUID
+	UserAgent string
+	IpAddress string
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.SessionID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
+	var i RefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.SessionID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.SessionStartedAt,
+		&i.ReplacedBy,
+		&i.ReuseDetectedAt,
+	)
+	return i, err
+}
+
+const createRotatedRefreshToken = `-- name: CreateRotatedRefreshToken :one
+INSERT INTO refresh_tokens(
+    token_hash,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at,
+    session_id,
+    session_started_at,
+    user_agent,
+    ip_address,
+    last_used_at
+)
+VALUES(
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    NOW()
+)
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, session_id, user_agent, ip_address, last_used_at, session_started_at, replaced_by, reuse_detected_at
+`
+
+type CreateRotatedRefreshTokenParams struct {
+	TokenHash        string
+	UserID           uuid.UUID
+	ExpiresAt        time.Time
+	SessionID        uuid.UUID
+	SessionStartedAt time.Time
+	UserAgent        string
+	IpAddress        string
+}
+
+func (q *Queries) CreateRotatedRefreshToken(ctx context.Context, arg CreateRotatedRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, createRotatedRefreshToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.SessionID,
+		arg.SessionStartedAt,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.SessionID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.SessionStartedAt,
+		&i.ReplacedBy,
+		&i.ReuseDetectedAt,
 	)
 	return i, err
 }
 
 const findRefreshToken = `-- name: FindRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens WHERE token = $1 AND revoked_at IS NULL LIMIT 1
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, session_id, user_agent, ip_address, last_used_at, session_started_at, replaced_by, reuse_detected_at FROM refresh_tokens WHERE token_hash = $1 AND revoked_at IS NULL LIMIT 1
+`
+
+func (q *Queries) FindRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, findRefreshToken, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.SessionID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.SessionStartedAt,
+		&i.ReplacedBy,
+		&i.ReuseDetectedAt,
+	)
+	return i, err
+}
+
+const findRefreshTokenForUpdate = `-- name: FindRefreshTokenForUpdate :one
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, session_id, user_agent, ip_address, last_used_at, session_started_at, replaced_by, reuse_detected_at FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE
 `
 
-func (q *Queries) FindRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, findRefreshToken, token)
+func (q *Queries) FindRefreshTokenForUpdate(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, findRefreshTokenForUpdate, tokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.SessionID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.SessionStartedAt,
+		&i.ReplacedBy,
+		&i.ReuseDetectedAt,
 	)
 	return i, err
 }
 
+const flagRefreshTokenReuse = `-- name: FlagRefreshTokenReuse :exec
+UPDATE refresh_tokens SET reuse_detected_at = NOW(), updated_at = NOW() WHERE token_hash = $1
+`
+
+func (q *Queries) FlagRefreshTokenReuse(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, flagRefreshTokenReuse, tokenHash)
+	return err
+}
+
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, session_id, user_agent, ip_address, last_used_at, session_started_at, replaced_by, reuse_detected_at FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY COALESCE(last_used_at, created_at) DESC, session_id
+`
+
+func (q *Queries) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.SessionID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+			&i.SessionStartedAt,
+			&i.ReplacedBy,
+			&i.ReuseDetectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, session_id, user_agent, ip_address, last_used_at, session_started_at, replaced_by, reuse_detected_at FROM (
+    SELECT DISTINCT ON (session_id) * FROM refresh_tokens
+    WHERE user_id = $1
+    ORDER BY session_id, created_at DESC
+) AS sessions
+ORDER BY session_started_at, session_id
+`
+
+// Returns the latest token of each session of the user, which describes the
+// session as a whole.
+func (q *Queries) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.SessionID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+			&i.SessionStartedAt,
+			&i.ReplacedBy,
+			&i.ReuseDetectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeStaleRefreshTokens = `-- name: PurgeStaleRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE token_hash IN (
+    SELECT token_hash FROM refresh_tokens AS stale
+    WHERE stale.expires_at < NOW()
+        OR (stale.revoked_at < $1 AND NOT EXISTS (
+            SELECT 1 FROM refresh_tokens AS live
+            WHERE live.session_id = stale.session_id AND live.revoked_at IS NULL
+        ))
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type PurgeStaleRefreshTokensParams struct {
+	RevokedBefore sql.NullTime
+	MaxTokens     int32
+}
+
+// Rotated tokens are kept as long as their session can be refreshed, so that
+// their replay is detected. Sessions are deleted once expired, or once signed
+// out for long enough.
+func (q *Queries) PurgeStaleRefreshTokens(ctx context.Context, arg PurgeStaleRefreshTokensParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeStaleRefreshTokens, arg.RevokedBefore, arg.MaxTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const replaceRefreshToken = `-- name: ReplaceRefreshToken :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW(), replaced_by = $2
+WHERE token_hash = $1
+`
+
+type ReplaceRefreshTokenParams struct {
+	TokenHash  string
+	ReplacedBy sql.NullString
+}
+
+func (q *Queries) ReplaceRefreshToken(ctx context.Context, arg ReplaceRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, replaceRefreshToken, arg.TokenHash, arg.ReplacedBy)
+	return err
+}
+
 const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
-UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token_hash = $1
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, tokenHash)
+	return err
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND session_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeSession, arg.UserID, arg.SessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
 	return err
 }
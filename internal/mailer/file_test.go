@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := NewFileMailer(dir)
+
+	err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hello", Body: "Token: 123"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one .eml file, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Failed to read message: %s", err)
+	}
+	for _, want := range []string{"To: alice@example.com\r\n", "Subject: Hello\r\n", "Token: 123"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("Message = %q, expected to contain %q", data, want)
+		}
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+	ctx := context.Background()
+
+	if _, err := m.Last("alice@example.com"); err == nil {
+		t.Fatalf("Last() expected an error before any message is sent")
+	}
+	m.Send(ctx, Message{To: "alice@example.com", Subject: "First"})
+	m.Send(ctx, Message{To: "bob@example.com", Subject: "Other"})
+	m.Send(ctx, Message{To: "alice@example.com", Subject: "Second"})
+
+	if n := len(m.Messages()); n != 3 {
+		t.Fatalf("Messages() length = %d, expected = 3", n)
+	}
+	last, err := m.Last("alice@example.com")
+	if err != nil {
+		t.Fatalf("Last() error = %v", err)
+	}
+	if last.Subject != "Second" {
+		t.Fatalf("Last() subject = %q, expected = %q", last.Subject, "Second")
+	}
+}
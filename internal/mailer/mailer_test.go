@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(log.New(&buf, "", 0))
+
+	err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hello", Body: "Token: 123"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	for _, want := range []string{"alice@example.com", "Hello", "Token: 123"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("Send() output = %q, expected to contain %q", buf.String(), want)
+		}
+	}
+}
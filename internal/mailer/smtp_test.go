@@ -0,0 +1,129 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestValidAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    bool
+	}{
+		{"simple", "alice@example.com", true},
+		{"subdomain and plus", "alice+chirpy@mail.example.co.uk", true},
+		{"empty", "", false},
+		{"no at sign", "alice.example.com", false},
+		{"no domain dot", "alice@localhost", false},
+		{"display name", "Alice <alice@example.com>", false},
+		{"spaces", " alice@example.com", false},
+		{"two at signs", "alice@@example.com", false},
+		{"header injection", "alice@example.com\r\nBcc: bob@example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidAddress(tt.address); got != tt.want {
+				t.Errorf("ValidAddress(%q) = %v, expected = %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMessageRejectsHeaderInjection(t *testing.T) {
+	_, err := formatMessage("chirpy@example.com", Message{To: "alice@example.com\r\nBcc: bob@example.com"})
+	if err == nil {
+		t.Fatalf("formatMessage() expected an error")
+	}
+}
+
+// fakeSMTP accepts a single SMTP session and sends the received DATA on
+// the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 ok")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+	m := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "chirpy@example.com"})
+
+	err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Héllo", Body: "Token: 123\n"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	data := <-received
+	for _, want := range []string{
+		"From: chirpy@example.com\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?q?H=C3=A9llo?=\r\n",
+		"\r\n\r\nToken: 123\r\n",
+	} {
+		if !strings.Contains(data, want) {
+			t.Fatalf("Received message = %q, expected to contain %q", data, want)
+		}
+	}
+}
+
+func TestSMTPMailerRejectsInvalidAddress(t *testing.T) {
+	m := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: "1", From: "chirpy@example.com"})
+	err := m.Send(context.Background(), Message{To: "not an address"})
+	if !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("Send() error = %v, expected %v", err, ErrInvalidAddress)
+	}
+}
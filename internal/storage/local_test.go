@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoragePutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "/app/media/")
+
+	data := []byte("hello")
+	if err := s.Put(context.Background(), "ab/file.png", data, "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, "ab", "file.png"))
+	if err != nil {
+		t.Fatalf("Failed to read stored file: %s", err)
+	}
+	if string(stored) != "hello" {
+		t.Fatalf("Put() stored = %q, expected = %q", stored, data)
+	}
+
+	if url := s.URL("ab/file.png"); url != "/app/media/ab/file.png" {
+		t.Fatalf("URL() expected = /app/media/ab/file.png, actual = %v", url)
+	}
+
+	if err := s.Delete(context.Background(), "ab/file.png"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ab", "file.png")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected file to be deleted: %v", err)
+	}
+	if err := s.Delete(context.Background(), "ab/file.png"); err != nil {
+		t.Fatalf("Delete() of missing file error = %v", err)
+	}
+}
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/app/media")
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b", `a\b`} {
+		t.Run(key, func(t *testing.T) {
+			err := s.Put(context.Background(), key, []byte("x"), "text/plain")
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Put(%q) error = %v, expected ErrInvalidKey", key, err)
+			}
+		})
+	}
+}
+
+func TestLocalStorageServeHTTP(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/app/media")
+	if err := s.Put(context.Background(), "ab/file.png", []byte("hello"), "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/ab/file.png", wantStatus: http.StatusOK, wantBody: "hello"},
+		{path: "/ab/missing.png", wantStatus: http.StatusNotFound},
+		{path: "/ab/", wantStatus: http.StatusNotFound},
+		{path: "/ab", wantStatus: http.StatusNotFound},
+		{path: "/", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("ServeHTTP(%q) status = %d, expected = %d", tc.path, rec.Code, tc.wantStatus)
+			}
+			if tc.wantBody != "" && rec.Body.String() != tc.wantBody {
+				t.Fatalf("ServeHTTP(%q) body = %q, expected = %q", tc.path, rec.Body, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Package storage stores uploaded media files in a pluggable backend.
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Storage saves objects under a key and exposes them at a public URL.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// validateKey rejects keys that could escape the storage root. Keys are
+// slash separated relative paths without empty, "." or ".." segments.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runPeriodically calls job right away and then every interval until ctx
+// is canceled.
+func runPeriodically(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (ac *apiConfig) handlerLikeChirp(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	_, err = ac.findVisibleChirp(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	_, err = ac.dbQueries.CreateLike(req.Context(), database.CreateLikeParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerUnlikeChirp(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	rowsAffected, err := ac.dbQueries.DeleteLike(req.Context(), database.DeleteLikeParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s is not liked", chirpID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListUserLikes lists the chirps liked by the user in the path,
+// most recently liked first by default.
+func (ac *apiConfig) handlerListUserLikes(rw http.ResponseWriter, req *http.Request) {
+	userID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	_, err = ac.dbQueries.FindUserById(req.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User %s not found", userID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	type likedChirp struct {
+		chirp  database.Chirp
+		cursor pageCursor
+	}
+	var liked []likedChirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		rows, err := ac.dbQueries.ListLikedChirpsAsc(req.Context(), database.ListLikedChirpsAscParams{
+			UserID:          userID,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get liked chirps", err)
+			return
+		}
+		for _, row := range rows {
+			liked = append(liked, likedChirp{row.Chirp, pageCursor{CreatedAt: row.LikedAt, ID: row.Chirp.ID}})
+		}
+	} else {
+		rows, err := ac.dbQueries.ListLikedChirpsDesc(req.Context(), database.ListLikedChirpsDescParams{
+			UserID:          userID,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to get liked chirps", err)
+			return
+		}
+		for _, row := range rows {
+			liked = append(liked, likedChirp{row.Chirp, pageCursor{CreatedAt: row.LikedAt, ID: row.Chirp.ID}})
+		}
+	}
+
+	liked, links := paginate(page, liked, func(l likedChirp) pageCursor { return l.cursor })
+	chirps := make([]database.Chirp, 0, len(liked))
+	for _, l := range liked {
+		chirps = append(chirps, l.chirp)
+	}
+	response, err := ac.buildChirpInfos(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get liked chirps", err)
+		return
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"chirpy/internal/database"
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -46,23 +47,103 @@ func main() {
 
 	dbQueries := database.New(db)
 
-	apiCfg := apiConfig{dbQueries: dbQueries, platform: os.Getenv("PLATFORM"), tokenSecret: tokenSecret, polkaKey: polkaKey}
+	mediaStorage, err := newMediaStorage()
+	if err != nil {
+		log.Fatalf("Failed to configure media storage: %s\n", err)
+		return
+	}
+
+	mailSender, err := newMailer()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %s\n", err)
+		return
+	}
+
+	apiCfg := apiConfig{db: db, dbQueries: dbQueries, platform: os.Getenv("PLATFORM"), tokenSecret: tokenSecret, polkaKey: polkaKey, mediaStorage: mediaStorage, mailer: mailSender, passwordResetSlots: make(chan struct{}, maxPendingPasswordResets)}
 	mux := http.NewServeMux()
 
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", http.FileServer(http.Dir(".")))))
+	if handler, ok := mediaStorage.(http.Handler); ok {
+		mux.Handle(localMediaURL, http.StripPrefix(localMediaURL, handler))
+	}
 	mux.HandleFunc("GET /api/healthz", handlerHealthz)
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
+	mux.HandleFunc("POST /api/users/verify", apiCfg.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", apiCfg.handlerResendEmailVerification)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.handlerLoginMFA)
+	mux.HandleFunc("POST /api/password/forgot", apiCfg.handlerForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apiCfg.handlerResetPassword)
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerListAllChirps)
 	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.GetChirpById)
+	mux.HandleFunc("GET /api/chirps/{id}/thread", apiCfg.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{id}/likes", apiCfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}/likes", apiCfg.handlerUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{id}/rechirps", apiCfg.handlerRechirp)
 	mux.HandleFunc("DELETE /api/chirps/{id}", apiCfg.handlerDeleteChirp)
+	mux.HandleFunc("PATCH /api/chirps/{id}", apiCfg.handlerEditChirp)
+	mux.HandleFunc("GET /api/chirps/{id}/history", apiCfg.handlerChirpHistory)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefreshToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevokeRefreshToken)
+	mux.HandleFunc("GET /api/sessions", apiCfg.handlerListSessions)
+	mux.HandleFunc("DELETE /api/sessions", apiCfg.handlerRevokeAllSessions)
+	mux.HandleFunc("DELETE /api/sessions/{id}", apiCfg.handlerRevokeSession)
 	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
+	mux.HandleFunc("GET /api/users/me", apiCfg.handlerGetCurrentUser)
+	mux.HandleFunc("PATCH /api/users/me", apiCfg.handlerUpdateUser)
+	mux.HandleFunc("DELETE /api/users/me", apiCfg.handlerDeleteCurrentUser)
+	mux.HandleFunc("GET /api/users/me/export", apiCfg.handlerExportCurrentUser)
+	mux.HandleFunc("POST /api/users/me/password", apiCfg.handlerChangePassword)
+	mux.HandleFunc("POST /api/users/me/email", apiCfg.handlerRequestEmailChange)
+	mux.HandleFunc("POST /api/users/me/email/confirm", apiCfg.handlerConfirmEmailChange)
+	mux.HandleFunc("POST /api/users/me/totp", apiCfg.handlerStartTOTPEnrollment)
+	mux.HandleFunc("POST /api/users/me/totp/confirm", apiCfg.handlerConfirmTOTPEnrollment)
+	mux.HandleFunc("DELETE /api/users/me/totp", apiCfg.handlerDisableTOTP)
+	mux.HandleFunc("GET /api/users/{handle}", apiCfg.handlerGetProfile)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaWebhook)
+	mux.HandleFunc("POST /api/users/{id}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("POST /api/users/{id}/block", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{id}/block", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{id}/mute", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{id}/mute", apiCfg.handlerUnmuteUser)
+	mux.HandleFunc("GET /api/me/blocks", apiCfg.handlerListBlocks)
+	mux.HandleFunc("GET /api/me/mutes", apiCfg.handlerListMutes)
+	mux.HandleFunc("GET /api/users/{id}/followers", apiCfg.handlerListFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", apiCfg.handlerListFollowing)
+	mux.HandleFunc("GET /api/users/{id}/likes", apiCfg.handlerListUserLikes)
+	mux.HandleFunc("GET /api/timeline", apiCfg.handlerTimeline)
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.handlerTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.handlerListTagChirps)
+	mux.HandleFunc("GET /api/mentions", apiCfg.handlerListMentions)
+	mux.HandleFunc("GET /api/search/chirps", apiCfg.handlerSearchChirps)
+	mux.HandleFunc("POST /api/media", apiCfg.handlerUploadMedia)
+	mux.HandleFunc("GET /api/me/drafts", apiCfg.handlerListDrafts)
+	mux.HandleFunc("GET /api/me/scheduled", apiCfg.handlerListScheduledChirps)
+	mux.HandleFunc("POST /api/chirps/{id}/publish", apiCfg.handlerPublishChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}/schedule", apiCfg.handlerCancelScheduledChirp)
+	mux.HandleFunc("POST /api/chirps/{id}/poll/votes", apiCfg.handlerVoteInPoll)
+	mux.HandleFunc("GET /api/me/trash", apiCfg.handlerListTrash)
+	mux.HandleFunc("POST /api/chirps/{id}/restore", apiCfg.handlerRestoreChirp)
+	mux.HandleFunc("POST /api/chirps/{id}/pin", apiCfg.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}/pin", apiCfg.handlerUnpinChirp)
+	mux.HandleFunc("POST /api/collections", apiCfg.handlerCreateCollection)
+	mux.HandleFunc("GET /api/collections", apiCfg.handlerListCollections)
+	mux.HandleFunc("GET /api/collections/{id}", apiCfg.handlerGetCollection)
+	mux.HandleFunc("PUT /api/collections/{id}", apiCfg.handlerRenameCollection)
+	mux.HandleFunc("DELETE /api/collections/{id}", apiCfg.handlerDeleteCollection)
+	mux.HandleFunc("GET /api/collections/{id}/chirps", apiCfg.handlerListCollectionChirps)
+	mux.HandleFunc("POST /api/collections/{id}/chirps", apiCfg.handlerAddChirpToCollection)
+	mux.HandleFunc("DELETE /api/collections/{id}/chirps/{chirp_id}", apiCfg.handlerRemoveChirpFromCollection)
+
+	go runPeriodically(context.Background(), schedulerInterval, apiCfg.publishDueChirps)
+	go runPeriodically(context.Background(), trashPurgeInterval, apiCfg.purgeExpiredTrash)
+	go runPeriodically(context.Background(), mediaPurgeInterval, apiCfg.purgeUnattachedMedia)
+	go runPeriodically(context.Background(), mfaChallengePurgeInterval, apiCfg.purgeExpiredMFAChallenges)
+	go runPeriodically(context.Background(), sessionPurgeInterval, apiCfg.purgeStaleRefreshTokens)
 
 	server := http.Server{
 		Addr:    ":8080",
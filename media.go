@@ -0,0 +1,228 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaSize     = 5 << 20
+	maxMediaPerChirp = 4
+	// maxMultipartOverhead leaves room for the multipart boundaries and
+	// part headers around the uploaded file.
+	maxMultipartOverhead = 64 << 10
+
+	// unattachedMediaTTL is how long uploads can wait to be attached to a
+	// chirp before they are deleted.
+	unattachedMediaTTL = 24 * time.Hour
+	mediaPurgeInterval = time.Hour
+	mediaPurgeBatch    = 500
+)
+
+// mediaExtensions lists the accepted media types, as detected from the
+// content of the upload, with the file extension used to store them.
+var mediaExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"video/mp4":  ".mp4",
+}
+
+var errInvalidMedia = errors.New("invalid media")
+
+type mediaInfo struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+}
+
+func (ac *apiConfig) newMediaInfo(media database.Medium) mediaInfo {
+	return mediaInfo{
+		ID:          media.ID,
+		URL:         ac.mediaStorage.URL(media.StorageKey),
+		ContentType: media.ContentType,
+		SizeBytes:   media.SizeBytes,
+	}
+}
+
+// handlerUploadMedia stores the file sent in the `file` field of a
+// multipart form. The returned ID can then be attached to a new chirp.
+func (ac *apiConfig) handlerUploadMedia(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	req.Body = http.MaxBytesReader(rw, req.Body, maxMediaSize+maxMultipartOverhead)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Expected a multipart form", err)
+		return
+	}
+
+	var data []byte
+	for data == nil {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				respondWithError(rw, http.StatusBadRequest, "File not specified", nil)
+				return
+			}
+			respondWithMediaReadError(rw, err)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		data, err = io.ReadAll(io.LimitReader(part, maxMediaSize+1))
+		if err != nil {
+			respondWithMediaReadError(rw, err)
+			return
+		}
+	}
+	if len(data) > maxMediaSize {
+		respondWithError(rw, http.StatusRequestEntityTooLarge, fmt.Sprintf("File is too large. Must be at most %d bytes", maxMediaSize), nil)
+		return
+	}
+	if len(data) == 0 {
+		respondWithError(rw, http.StatusBadRequest, "File is empty", nil)
+		return
+	}
+
+	// The type declared by the client is ignored in favor of the one
+	// detected from the content.
+	contentType := http.DetectContentType(data)
+	extension, ok := mediaExtensions[contentType]
+	if !ok {
+		respondWithError(rw, http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported media type '%s'", contentType), nil)
+		return
+	}
+
+	mediaID := uuid.New()
+	key := mediaID.String() + extension
+	if err := ac.mediaStorage.Put(req.Context(), key, data, contentType); err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to store media", err)
+		return
+	}
+
+	media, err := ac.dbQueries.CreateMedia(req.Context(), database.CreateMediaParams{
+		ID:          mediaID,
+		UserID:      userID,
+		StorageKey:  key,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+	})
+	if err != nil {
+		ac.mediaStorage.Delete(context.WithoutCancel(req.Context()), key)
+		respondWithError(rw, http.StatusInternalServerError, "Failed to store media", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusCreated, ac.newMediaInfo(media))
+}
+
+func respondWithMediaReadError(rw http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(rw, http.StatusRequestEntityTooLarge, fmt.Sprintf("File is too large. Must be at most %d bytes", maxMediaSize), err)
+		return
+	}
+	respondWithError(rw, http.StatusBadRequest, "Invalid multipart form", err)
+}
+
+// attachChirpMedia attaches uploaded media to a new chirp in the given
+// order. It fails with errInvalidMedia if any of the media does not exist,
+// belongs to another user or is already attached to a chirp.
+func attachChirpMedia(ctx context.Context, qtx *database.Queries, chirp database.Chirp, mediaIDs []uuid.UUID) error {
+	for position, mediaID := range mediaIDs {
+		attached, err := qtx.AttachMediaToChirp(ctx, database.AttachMediaToChirpParams{
+			ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Position: int32(position),
+			ID:       mediaID,
+			UserID:   chirp.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		if attached == 0 {
+			return fmt.Errorf("%w: %s", errInvalidMedia, mediaID)
+		}
+	}
+	return nil
+}
+
+// deleteStoredMedia removes files whose rows were deleted. Failures are
+// only logged, as the files are no longer referenced.
+func (ac *apiConfig) deleteStoredMedia(ctx context.Context, storageKeys []string) {
+	for _, key := range storageKeys {
+		if err := ac.mediaStorage.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete media %s: %s\n", key, err)
+		}
+	}
+}
+
+// purgeUnattachedMedia deletes the uploads that were never attached to a
+// chirp within unattachedMediaTTL.
+func (ac *apiConfig) purgeUnattachedMedia(ctx context.Context) {
+	for {
+		storageKeys, err := ac.dbQueries.DeleteUnattachedMedia(ctx, database.DeleteUnattachedMediaParams{
+			CreatedBefore: time.Now().Add(-unattachedMediaTTL),
+			MaxMedia:      mediaPurgeBatch,
+		})
+		if err != nil {
+			log.Printf("Failed to purge unattached media: %s\n", err)
+			return
+		}
+		ac.deleteStoredMedia(ctx, storageKeys)
+		if len(storageKeys) > 0 {
+			log.Printf("Purged %d unattached media\n", len(storageKeys))
+		}
+		if len(storageKeys) < mediaPurgeBatch {
+			return
+		}
+	}
+}
+
+// localMediaDir is where the local backend stores media, relative to the
+// working directory. The backend serves it at localMediaURL, which takes
+// precedence over the /app/ file server so that it is never listed.
+const (
+	localMediaDir = "media"
+	localMediaURL = "/app/media/"
+)
+
+// newMediaStorage configures the media backend from the environment.
+// MEDIA_STORAGE selects "local" (the default) or "s3".
+func newMediaStorage() (storage.Storage, error) {
+	switch backend := os.Getenv("MEDIA_STORAGE"); backend {
+	case "", "local":
+		return storage.NewLocalStorage(localMediaDir, localMediaURL), nil
+	case "s3":
+		config := storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		}
+		if config.Endpoint == "" || config.Region == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+			return nil, errors.New("S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY envvars must be set")
+		}
+		return storage.NewS3Storage(config), nil
+	default:
+		return nil, fmt.Errorf("unknown media storage '%s'", backend)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// handlerListMentions lists the chirps of other users mentioning the
+// authenticated user, most recent first by default.
+func (ac *apiConfig) handlerListMentions(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirps []database.Chirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		chirps, err = ac.dbQueries.ListMentioningChirpsAsc(req.Context(), database.ListMentioningChirpsAscParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		chirps, err = ac.dbQueries.ListMentioningChirpsDesc(req.Context(), database.ListMentioningChirpsDescParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get mentions", err)
+		return
+	}
+
+	chirps, links := paginate(page, chirps, chirpCursor)
+	response, err := ac.buildChirpInfos(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get mentions", err)
+		return
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"chirpy/internal/database"
+	"chirpy/internal/mailer"
+	"chirpy/internal/storage"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"sync/atomic"
@@ -9,10 +12,16 @@ import (
 
 type apiConfig struct {
 	fileServerHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	platform       string
 	tokenSecret    string
 	polkaKey       string
+	mediaStorage   storage.Storage
+	mailer         mailer.Mailer
+	// passwordResetSlots holds a value for each password reset email
+	// being sent.
+	passwordResetSlots chan struct{}
 }
 
 func (ac *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
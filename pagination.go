@@ -0,0 +1,207 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageCursor identifies a row in a listing ordered by (created_at, id).
+// It is handed to clients as an opaque string.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c pageCursor) encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageCursor(value string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	createdAtString, idString, found := strings.Cut(string(raw), "|")
+	if !found {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	return pageCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// pageRequest describes which page of a listing the client asked for.
+// Backward is set when paging with a `before` cursor, i.e. towards the
+// start of the listing. FromStart is set by `from_start` for a page that
+// starts at the top of the listing but follows a page of rows shown ahead
+// of it, such as pinned chirps.
+type pageRequest struct {
+	Limit     int32
+	Cursor    *pageCursor
+	Backward  bool
+	Desc      bool
+	FromStart bool
+}
+
+func parsePageRequest(query url.Values, defaultSortOrder string) (pageRequest, error) {
+	page := pageRequest{Limit: defaultPageSize}
+
+	sortOrder := query.Get("sort")
+	if sortOrder == "" {
+		sortOrder = defaultSortOrder
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		return page, fmt.Errorf("Invalid sort order '%s'. Must be either 'asc' or 'desc'", sortOrder)
+	}
+	page.Desc = sortOrder == "desc"
+
+	if limitString := query.Get("limit"); limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return page, fmt.Errorf("Invalid limit '%s'. Must be between 1 and %d", limitString, maxPageSize)
+		}
+		page.Limit = int32(limit)
+	}
+
+	after, before := query.Get("after"), query.Get("before")
+	if after != "" && before != "" {
+		return page, errors.New("Only one of 'after' and 'before' can be specified")
+	}
+	if fromStart := query.Get("from_start"); fromStart != "" {
+		var err error
+		page.FromStart, err = strconv.ParseBool(fromStart)
+		if err != nil {
+			return page, fmt.Errorf("Invalid from_start '%s'. Must be a boolean", fromStart)
+		}
+		if page.FromStart && (after != "" || before != "") {
+			return page, errors.New("'from_start' cannot be combined with a cursor")
+		}
+	}
+	cursorString := after
+	if before != "" {
+		cursorString = before
+		page.Backward = true
+	}
+	if cursorString != "" {
+		cursor, err := decodePageCursor(cursorString)
+		if err != nil {
+			return page, errors.New("Invalid cursor")
+		}
+		page.Cursor = &cursor
+	}
+	return page, nil
+}
+
+// ascending reports whether the rows of the page have to be fetched in
+// ascending (created_at, id) order. Paging backward walks the listing in
+// the opposite direction of the requested sort order.
+func (p pageRequest) ascending() bool {
+	return p.Desc == p.Backward
+}
+
+// fetchLimit is one more than the page size so that the presence of a
+// further page can be detected without a separate count query.
+func (p pageRequest) fetchLimit() int32 {
+	return p.Limit + 1
+}
+
+func (p pageRequest) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}, uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// pageLinks holds the encoded cursors of the pages adjacent to the
+// current one. Empty values mean there is no such page, unless
+// NextFromStart is set for a next page starting at the top of the listing.
+type pageLinks struct {
+	Next          string
+	Prev          string
+	NextFromStart bool
+}
+
+// paginate trims the extra row fetched by fetchLimit, restores the
+// requested sort order and computes the cursors of the adjacent pages.
+func paginate[T any](p pageRequest, rows []T, key func(T) pageCursor) ([]T, pageLinks) {
+	hasMore := len(rows) > int(p.Limit)
+	if hasMore {
+		rows = rows[:p.Limit]
+	}
+	if p.Backward {
+		slices.Reverse(rows)
+	}
+
+	links := pageLinks{}
+	if p.Backward {
+		if hasMore {
+			links.Prev = key(rows[0]).encode()
+		}
+		if len(rows) > 0 {
+			links.Next = key(rows[len(rows)-1]).encode()
+		} else {
+			links.Next = p.Cursor.encode()
+		}
+		return rows, links
+	}
+
+	if hasMore {
+		links.Next = key(rows[len(rows)-1]).encode()
+	}
+	if p.Cursor != nil {
+		if len(rows) > 0 {
+			links.Prev = key(rows[0]).encode()
+		} else {
+			links.Prev = p.Cursor.encode()
+		}
+	}
+	return rows, links
+}
+
+// setPaginationLinks advertises the adjacent pages through a Link header
+// (RFC 8288) so the response body can stay a plain list.
+func setPaginationLinks(rw http.ResponseWriter, req *http.Request, links pageLinks) {
+	pageURL := func(param, cursor string) string {
+		query := req.URL.Query()
+		query.Del("after")
+		query.Del("before")
+		query.Del("from_start")
+		query.Set(param, cursor)
+		return req.URL.Path + "?" + query.Encode()
+	}
+
+	values := []string{}
+	if links.NextFromStart {
+		values = append(values, fmt.Sprintf(`<%s>; rel="next"`, pageURL("from_start", "true")))
+	} else if links.Next != "" {
+		values = append(values, fmt.Sprintf(`<%s>; rel="next"`, pageURL("after", links.Next)))
+	}
+	if links.Prev != "" {
+		values = append(values, fmt.Sprintf(`<%s>; rel="prev"`, pageURL("before", links.Prev)))
+	}
+	if len(values) > 0 {
+		rw.Header().Set("Link", strings.Join(values, ", "))
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/mailer"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	tokenPurposePasswordReset = "password_reset"
+	passwordResetTokenTTL     = time.Hour
+	passwordResetSendTimeout  = 30 * time.Second
+	// passwordResetCooldown is how long after sending a reset token to a
+	// user further requests for them are ignored.
+	passwordResetCooldown = 5 * time.Minute
+	// maxPendingPasswordResets bounds the reset emails being sent at once.
+	// Requests beyond it are dropped.
+	maxPendingPasswordResets = 16
+)
+
+// handlerForgotPassword emails a password reset token to the user with the
+// given email. It responds the same way whether or not the email belongs to
+// a user, and sends the email in the background so the response time does
+// not tell either. Neither does it tell whether the request was throttled.
+func (ac *apiConfig) handlerForgotPassword(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Email string `json:"email"`
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if !mailer.ValidAddress(params.Email) {
+		respondWithError(rw, http.StatusBadRequest, "Invalid email", nil)
+		return
+	}
+
+	select {
+	case ac.passwordResetSlots <- struct{}{}:
+	default:
+		log.Printf("Too many pending password reset emails, dropping request\n")
+		rw.WriteHeader(http.StatusAccepted)
+		return
+	}
+	go func() {
+		defer func() { <-ac.passwordResetSlots }()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), passwordResetSendTimeout)
+		defer cancel()
+		if err := ac.sendPasswordReset(ctx, params.Email); err != nil {
+			log.Printf("Failed to send password reset email: %s\n", err)
+		}
+	}()
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (ac *apiConfig) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := ac.dbQueries.FindUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	recent, err := ac.dbQueries.HasRecentAccountToken(ctx, database.HasRecentAccountTokenParams{
+		UserID:       user.ID,
+		Purpose:      tokenPurposePasswordReset,
+		CreatedAfter: time.Now().Add(-passwordResetCooldown),
+	})
+	if err != nil || recent {
+		return err
+	}
+	token, err := ac.issueAccountToken(ctx, user.ID, tokenPurposePasswordReset, user.Email, passwordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+	return ac.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Reset the password of your Chirpy account by sending the following token to POST /api/password/reset:\n\n%s\n\nThe token expires in %s. If you did not ask for a new password, you can ignore this email.",
+			token, passwordResetTokenTTL),
+	})
+}
+
+// handlerResetPassword sets a new password for the user the reset token was
+// issued to, and signs them out everywhere by revoking their refresh tokens.
+func (ac *apiConfig) handlerResetPassword(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if err := validatePassword(params.NewPassword); err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	hashedPassword, err := auth.HashPassword(params.NewPassword)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		token, err := qtx.ConsumeAccountToken(req.Context(), database.ConsumeAccountTokenParams{
+			TokenHash: auth.HashToken(params.Token),
+			Purpose:   tokenPurposePasswordReset,
+		})
+		if err != nil {
+			return err
+		}
+		user, err := qtx.FindUserByIdForUpdate(req.Context(), token.UserID)
+		if err != nil {
+			return err
+		}
+		// The token was sent to an address the user no longer uses.
+		if user.Email != token.Email {
+			return sql.ErrNoRows
+		}
+		err = qtx.UpdateUserPassword(req.Context(), database.UpdateUserPasswordParams{
+			HashedPassword: hashedPassword,
+			ID:             user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return qtx.RevokeUserRefreshTokens(req.Context(), user.ID)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusBadRequest, "Invalid or expired token", err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to reset password", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/mailer"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestForgotPasswordThrottling(t *testing.T) {
+	user := database.User{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Email:     "user@example.com",
+	}
+
+	tests := []struct {
+		name         string
+		recent       bool
+		slotsTaken   bool
+		wantMessages int
+	}{
+		{
+			name:         "First request",
+			wantMessages: 1,
+		},
+		{
+			name:   "Token sent recently",
+			recent: true,
+		},
+		{
+			name:       "Too many pending emails",
+			slotsTaken: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			fake.on("FindUserByEmail", modelResult(user))
+			fake.on("HasRecentAccountToken", scalarResult(tt.recent))
+			fake.on("InvalidateAccountTokens", fakeResult{})
+			fake.on("CreateAccountToken", fakeResult{})
+			memoryMailer := mailer.NewMemoryMailer()
+			ac := &apiConfig{db: db, dbQueries: database.New(db), mailer: memoryMailer, passwordResetSlots: make(chan struct{}, 1)}
+			if tt.slotsTaken {
+				ac.passwordResetSlots <- struct{}{}
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/password/forgot", strings.NewReader(`{"email": "user@example.com"}`))
+			ac.handlerForgotPassword(rec, req)
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+			}
+			// Wait for the email to be sent by taking the slot it used.
+			if !tt.slotsTaken {
+				ac.passwordResetSlots <- struct{}{}
+			}
+
+			if got := len(memoryMailer.Messages()); got != tt.wantMessages {
+				t.Errorf("sent %d messages, want %d", got, tt.wantMessages)
+			}
+		})
+	}
+}
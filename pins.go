@@ -0,0 +1,111 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	maxPins          = 1
+	maxPinsChirpyRed = 5
+)
+
+var errPinLimitReached = errors.New("pin limit reached")
+
+func pinLimit(user database.User) int64 {
+	if user.IsChirpyRed {
+		return maxPinsChirpyRed
+	}
+	return maxPins
+}
+
+// handlerPinChirp pins a chirp to the profile of its author. Pinning a
+// chirp that is already pinned is a no-op.
+func (ac *apiConfig) handlerPinChirp(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	var limit int64
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		// Locking the user serializes concurrent pins so the limit holds.
+		user, err := qtx.FindUserByIdForUpdate(req.Context(), userID)
+		if err != nil {
+			return err
+		}
+		chirp, err := qtx.FindPublishedChirpById(req.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != userID {
+			return errForbidden
+		}
+
+		limit = pinLimit(user)
+		pinned, err := qtx.CountOtherPins(req.Context(), database.CountOtherPinsParams{
+			UserID:  userID,
+			ChirpID: chirp.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if pinned >= limit {
+			return errPinLimitReached
+		}
+		return qtx.CreatePin(req.Context(), database.CreatePinParams{
+			UserID:  userID,
+			ChirpID: chirp.ID,
+		})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+		case errors.Is(err, errForbidden):
+			respondWithError(rw, http.StatusForbidden, "Access Forbiden", nil)
+		case errors.Is(err, errPinLimitReached):
+			respondWithError(rw, http.StatusConflict, fmt.Sprintf("Cannot pin more than %d chirps", limit), nil)
+		default:
+			respondWithError(rw, http.StatusInternalServerError, "Failed to pin chirp", err)
+		}
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ac *apiConfig) handlerUnpinChirp(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	rowsAffected, err := ac.dbQueries.DeletePin(req.Context(), database.DeletePinParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s is not pinned", chirpID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+type pollRequest struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// pollInfo is the poll attached to a chirp. Vote counts are only shown
+// once the viewer has voted or the poll has closed.
+type pollInfo struct {
+	ClosesAt   time.Time        `json:"closes_at"`
+	Closed     bool             `json:"closed"`
+	Options    []pollOptionInfo `json:"options"`
+	TotalVotes *int64           `json:"total_votes"`
+	MyVote     *uuid.UUID       `json:"my_vote"`
+}
+
+type pollOptionInfo struct {
+	ID        uuid.UUID `json:"id"`
+	Label     string    `json:"label"`
+	VoteCount *int64    `json:"vote_count"`
+}
+
+// validatePoll checks a poll to be attached to a chirp published at
+// publishAt and returns its cleaned option labels.
+func validatePoll(poll pollRequest, publishAt time.Time) ([]string, error) {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return nil, fmt.Errorf("A poll must have between %d and %d options", minPollOptions, maxPollOptions)
+	}
+	labels := make([]string, 0, len(poll.Options))
+	seen := map[string]bool{}
+	for _, option := range poll.Options {
+		label := strings.TrimSpace(option)
+		if label == "" {
+			return nil, errors.New("Poll options cannot be empty")
+		}
+		if len(label) > maxPollOptionLength {
+			return nil, fmt.Errorf("Poll options must be at most %d characters long", maxPollOptionLength)
+		}
+		if seen[strings.ToLower(label)] {
+			return nil, errors.New("Poll options must be distinct")
+		}
+		seen[strings.ToLower(label)] = true
+		labels = append(labels, filterProfanity(label))
+	}
+	if err := validatePollWindow(poll.ClosesAt, publishAt); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// validatePollWindow checks that a poll closing at closesAt stays open for
+// a valid duration once its chirp is published at publishAt.
+func validatePollWindow(closesAt, publishAt time.Time) error {
+	if !closesAt.After(publishAt) {
+		return errors.New("Poll closing time must be after the chirp is published")
+	}
+	if closesAt.Sub(publishAt) > maxPollDuration {
+		return fmt.Errorf("Poll cannot stay open for more than %s", maxPollDuration)
+	}
+	return nil
+}
+
+func createPoll(ctx context.Context, qtx *database.Queries, chirp database.Chirp, labels []string, closesAt time.Time) error {
+	err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirp.ID,
+		ClosesAt: closesAt,
+	})
+	if err != nil {
+		return err
+	}
+	for position, label := range labels {
+		err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirp.ID,
+			Position: int32(position),
+			Label:    label,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPolls returns the polls attached to the given chirps, keyed by chirp
+// ID, as seen by the viewer.
+func (ac *apiConfig) loadPolls(ctx context.Context, viewerID uuid.NullUUID, chirpIDs []uuid.UUID) (map[uuid.UUID]*pollInfo, error) {
+	polls, err := ac.dbQueries.ListPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(polls) == 0 {
+		return map[uuid.UUID]*pollInfo{}, nil
+	}
+	pollChirpIDs := make([]uuid.UUID, 0, len(polls))
+	for _, poll := range polls {
+		pollChirpIDs = append(pollChirpIDs, poll.ChirpID)
+	}
+
+	options, err := ac.dbQueries.ListPollOptionsForChirps(ctx, pollChirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	votedOption := map[uuid.UUID]uuid.UUID{}
+	if viewerID.Valid {
+		votes, err := ac.dbQueries.ListPollVotesByUser(ctx, database.ListPollVotesByUserParams{
+			UserID:   viewerID.UUID,
+			ChirpIds: pollChirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			votedOption[vote.ChirpID] = vote.OptionID
+		}
+	}
+
+	now := time.Now()
+	pollByChirp := make(map[uuid.UUID]*pollInfo, len(polls))
+	for _, poll := range polls {
+		info := &pollInfo{
+			ClosesAt: poll.ClosesAt,
+			Closed:   !poll.ClosesAt.After(now),
+			Options:  []pollOptionInfo{},
+		}
+		if optionID, ok := votedOption[poll.ChirpID]; ok {
+			info.MyVote = &optionID
+		}
+		if info.Closed || info.MyVote != nil {
+			info.TotalVotes = new(int64)
+		}
+		pollByChirp[poll.ChirpID] = info
+	}
+	for _, option := range options {
+		info := pollByChirp[option.ChirpID]
+		optionInfo := pollOptionInfo{ID: option.ID, Label: option.Label}
+		if info.TotalVotes != nil {
+			voteCount := option.VoteCount
+			optionInfo.VoteCount = &voteCount
+			*info.TotalVotes += voteCount
+		}
+		info.Options = append(info.Options, optionInfo)
+	}
+	return pollByChirp, nil
+}
+
+// handlerVoteInPoll records the authenticated user's vote in the poll of
+// the chirp in the path and responds with the chirp and the poll results.
+func (ac *apiConfig) handlerVoteInPoll(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+
+	chirp, err := ac.findVisibleChirp(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	poll, err := ac.dbQueries.FindPollByChirpId(req.Context(), chirp.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s has no poll", chirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if !poll.ClosesAt.After(time.Now()) {
+		respondWithError(rw, http.StatusConflict, "Poll is closed", nil)
+		return
+	}
+	_, err = ac.dbQueries.FindPollOption(req.Context(), database.FindPollOptionParams{
+		ID:      params.OptionID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Poll option %s not found", params.OptionID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+
+	voted, err := ac.dbQueries.CreatePollVote(req.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirp.ID,
+		UserID:   userID,
+		OptionID: params.OptionID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to vote", err)
+		return
+	}
+	if voted == 0 {
+		respondWithError(rw, http.StatusConflict, "Already voted in this poll", nil)
+		return
+	}
+
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusCreated, response)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidatePollWindow(t *testing.T) {
+	publishAt := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		closesAt time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "Closes after publication",
+			closesAt: publishAt.Add(time.Hour),
+		},
+		{
+			name:     "Closes when published",
+			closesAt: publishAt,
+			wantErr:  true,
+		},
+		{
+			name:     "Closes before publication",
+			closesAt: publishAt.Add(-time.Hour),
+			wantErr:  true,
+		},
+		{
+			name:     "Open for too long",
+			closesAt: publishAt.Add(maxPollDuration + time.Hour),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := pollRequest{Options: []string{"Yes", "No"}, ClosesAt: tt.closesAt}
+			_, err := validatePoll(poll, publishAt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePoll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
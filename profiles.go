@@ -0,0 +1,102 @@
+package main
+
+import (
+	"chirpy/internal/chirptext"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxAvatarURLLength   = 2048
+)
+
+// profile is the public representation of a user. It must never include
+// the email address or any other private data.
+type profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         *string   `json:"handle"`
+	DisplayName    *string   `json:"display_name"`
+	Bio            *string   `json:"bio"`
+	AvatarURL      *string   `json:"avatar_url"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// parseProfileText validates an optional profile field. A nil value leaves
+// the field unchanged and an empty one clears it.
+func parseProfileText(value *string, field string, maxLength int) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	text := strings.TrimSpace(*value)
+	if utf8.RuneCountInString(text) > maxLength {
+		return sql.NullString{}, fmt.Errorf("%s must be at most %d characters long", field, maxLength)
+	}
+	return sql.NullString{String: text, Valid: true}, nil
+}
+
+func parseAvatarURL(value *string) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	if *value == "" {
+		return sql.NullString{String: "", Valid: true}, nil
+	}
+	if len(*value) > maxAvatarURLLength {
+		return sql.NullString{}, errors.New("Avatar URL is too long")
+	}
+	parsed, err := url.Parse(*value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return sql.NullString{}, errors.New("Avatar URL must be an absolute http or https URL")
+	}
+	return sql.NullString{String: *value, Valid: true}, nil
+}
+
+// handlerGetProfile returns the public profile of the user with the handle
+// in the path.
+func (ac *apiConfig) handlerGetProfile(rw http.ResponseWriter, req *http.Request) {
+	handle := chirptext.NormalizeHandle(req.PathValue("handle"))
+	if !chirptext.IsValidHandle(handle) {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User @%s not found", handle), nil)
+		return
+	}
+
+	user, err := ac.dbQueries.FindUserByHandle(req.Context(), sql.NullString{String: handle, Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("User @%s not found", handle), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get user", err)
+		return
+	}
+	counts, err := ac.dbQueries.CountFollows(req.Context(), user.ID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get user", err)
+		return
+	}
+
+	respondWithJSON(rw, http.StatusOK, profile{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		Handle:         nullStringPtr(user.Handle),
+		DisplayName:    nullStringPtr(user.DisplayName),
+		Bio:            nullStringPtr(user.Bio),
+		AvatarURL:      nullStringPtr(user.AvatarUrl),
+		IsChirpyRed:    user.IsChirpyRed,
+		FollowerCount:  counts.FollowerCount,
+		FollowingCount: counts.FollowingCount,
+	})
+}
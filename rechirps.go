@@ -0,0 +1,102 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// handlerRechirp shares the chirp in the path. Without a body it creates a
+// plain rechirp, with a body it creates a quote carrying the commentary.
+func (ac *apiConfig) handlerRechirp(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Body string `json:"body"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	original, err := ac.findVisibleChirp(req.Context(), viewerID, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	// Sharing a plain rechirp shares the chirp it points to instead.
+	if original.Kind == chirpKindRechirp && original.RechirpOf.Valid {
+		original, err = ac.findVisibleChirp(req.Context(), viewerID, original.RechirpOf.UUID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+				return
+			}
+			respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+			return
+		}
+	}
+
+	kind := chirpKindRechirp
+	body := ""
+	if params.Body != "" {
+		kind = chirpKindQuote
+		body, err = validateChirpBody(params.Body)
+		if err != nil {
+			respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+
+	var chirp database.Chirp
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		chirp, err = qtx.CreateRechirp(req.Context(), database.CreateRechirpParams{
+			Body:      body,
+			UserID:    userID,
+			Kind:      kind,
+			RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return indexChirpBody(req.Context(), qtx, chirp)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusConflict, fmt.Sprintf("Chirp %s already rechirped", original.ID), nil)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to create chirp", err)
+		return
+	}
+
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusCreated, response)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	schedulerInterval  = 15 * time.Second
+	schedulerBatchSize = 100
+)
+
+var (
+	errChirpAlreadyPublished = errors.New("chirp already published")
+	errChirpNotScheduled     = errors.New("chirp not scheduled")
+)
+
+// parseChirpStatus returns the status of a new chirp from the options given
+// when creating it.
+func parseChirpStatus(draft bool, publishAt *time.Time) (string, sql.NullTime, error) {
+	switch {
+	case draft && publishAt != nil:
+		return "", sql.NullTime{}, errors.New("A draft cannot have a publication time")
+	case draft:
+		return chirpStatusDraft, sql.NullTime{}, nil
+	case publishAt != nil:
+		if !publishAt.After(time.Now()) {
+			return "", sql.NullTime{}, errors.New("Publication time must be in the future")
+		}
+		return chirpStatusScheduled, sql.NullTime{Time: *publishAt, Valid: true}, nil
+	default:
+		return chirpStatusPublished, sql.NullTime{}, nil
+	}
+}
+
+func (ac *apiConfig) handlerListDrafts(rw http.ResponseWriter, req *http.Request) {
+	ac.listOwnChirpsByStatus(rw, req, chirpStatusDraft)
+}
+
+func (ac *apiConfig) handlerListScheduledChirps(rw http.ResponseWriter, req *http.Request) {
+	ac.listOwnChirpsByStatus(rw, req, chirpStatusScheduled)
+}
+
+func (ac *apiConfig) listOwnChirpsByStatus(rw http.ResponseWriter, req *http.Request, status string) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirps []database.Chirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		chirps, err = ac.dbQueries.ListUserChirpsByStatusAsc(req.Context(), database.ListUserChirpsByStatusAscParams{
+			UserID:          userID,
+			Status:          status,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		chirps, err = ac.dbQueries.ListUserChirpsByStatusDesc(req.Context(), database.ListUserChirpsByStatusDescParams{
+			UserID:          userID,
+			Status:          status,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+
+	chirps, links := paginate(page, chirps, chirpCursor)
+	response, err := ac.buildChirpInfos(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerPublishChirp publishes a draft or scheduled chirp right away, or
+// schedules it for the `publish_at` time given in the body.
+func (ac *apiConfig) handlerPublishChirp(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	status, publishAt, err := parseChirpStatus(false, params.PublishAt)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirp database.Chirp
+	var pollErr error
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		chirp, err = qtx.FindChirpByIdForUpdate(req.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != userID {
+			return errForbidden
+		}
+		if chirp.Status == chirpStatusPublished {
+			return errChirpAlreadyPublished
+		}
+		// The poll was checked against the publication time planned when the
+		// chirp was created, which may have changed since.
+		poll, err := qtx.FindPollByChirpId(req.Context(), chirp.ID)
+		if err == nil {
+			publishTime := time.Now()
+			if publishAt.Valid {
+				publishTime = publishAt.Time
+			}
+			if pollErr = validatePollWindow(poll.ClosesAt, publishTime); pollErr != nil {
+				return pollErr
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if status == chirpStatusScheduled {
+			chirp, err = qtx.UpdateChirpSchedule(req.Context(), database.UpdateChirpScheduleParams{
+				Status:    status,
+				PublishAt: publishAt,
+				ID:        chirp.ID,
+			})
+			return err
+		}
+		chirp, err = qtx.PublishChirp(req.Context(), chirp.ID)
+		return err
+	})
+	if pollErr != nil {
+		respondWithError(rw, http.StatusBadRequest, pollErr.Error(), nil)
+		return
+	}
+	if err != nil {
+		respondWithScheduleError(rw, chirpID, err)
+		return
+	}
+
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerCancelScheduledChirp turns a scheduled chirp back into a draft.
+func (ac *apiConfig) handlerCancelScheduledChirp(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	var chirp database.Chirp
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		chirp, err = qtx.FindChirpByIdForUpdate(req.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != userID {
+			return errForbidden
+		}
+		if chirp.Status != chirpStatusScheduled {
+			return errChirpNotScheduled
+		}
+		chirp, err = qtx.UpdateChirpSchedule(req.Context(), database.UpdateChirpScheduleParams{
+			Status: chirpStatusDraft,
+			ID:     chirp.ID,
+		})
+		return err
+	})
+	if err != nil {
+		respondWithScheduleError(rw, chirpID, err)
+		return
+	}
+
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+func respondWithScheduleError(rw http.ResponseWriter, chirpID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+	case errors.Is(err, errForbidden):
+		respondWithError(rw, http.StatusForbidden, "Access Forbiden", nil)
+	case errors.Is(err, errChirpAlreadyPublished):
+		respondWithError(rw, http.StatusConflict, fmt.Sprintf("Chirp %s is already published", chirpID), nil)
+	case errors.Is(err, errChirpNotScheduled):
+		respondWithError(rw, http.StatusConflict, fmt.Sprintf("Chirp %s is not scheduled", chirpID), nil)
+	default:
+		respondWithError(rw, http.StatusInternalServerError, "Failed to update chirp", err)
+	}
+}
+
+// publishDueChirps publishes the scheduled chirps that are due. Several
+// server instances can run it against the same database. Chirps whose poll
+// closed before they could be published are turned back into drafts, so
+// that their author can give the poll a new closing time.
+func (ac *apiConfig) publishDueChirps(ctx context.Context) {
+	for {
+		var due int
+		var published, unscheduled int64
+		err := ac.withTx(ctx, func(qtx *database.Queries) error {
+			rows, err := qtx.ListDueChirpsForUpdate(ctx, schedulerBatchSize)
+			if err != nil {
+				return err
+			}
+			due = len(rows)
+			publish, unschedule := splitDueChirps(rows, time.Now())
+			if len(publish) > 0 {
+				if published, err = qtx.PublishChirps(ctx, publish); err != nil {
+					return err
+				}
+			}
+			if len(unschedule) > 0 {
+				if unscheduled, err = qtx.UnscheduleChirps(ctx, unschedule); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to publish scheduled chirps: %s\n", err)
+			return
+		}
+		if published > 0 {
+			log.Printf("Published %d scheduled chirps\n", published)
+		}
+		if unscheduled > 0 {
+			log.Printf("Moved %d scheduled chirps with a closed poll back to drafts\n", unscheduled)
+		}
+		if due < schedulerBatchSize {
+			return
+		}
+	}
+}
+
+// splitDueChirps returns the IDs of the due chirps to publish and of those
+// to turn back into drafts because their poll is closed at now.
+func splitDueChirps(rows []database.ListDueChirpsForUpdateRow, now time.Time) (publish, unschedule []uuid.UUID) {
+	for _, row := range rows {
+		if row.PollClosesAt.Valid && !row.PollClosesAt.Time.After(now) {
+			unschedule = append(unschedule, row.Chirp.ID)
+			continue
+		}
+		publish = append(publish, row.Chirp.ID)
+	}
+	return publish, unschedule
+}
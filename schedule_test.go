@@ -0,0 +1,136 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSplitDueChirps(t *testing.T) {
+	now := time.Now()
+	withoutPoll := database.ListDueChirpsForUpdateRow{Chirp: database.Chirp{ID: uuid.New()}}
+	openPoll := database.ListDueChirpsForUpdateRow{
+		Chirp:        database.Chirp{ID: uuid.New()},
+		PollClosesAt: sql.NullTime{Time: now.Add(time.Hour), Valid: true},
+	}
+	closedPoll := database.ListDueChirpsForUpdateRow{
+		Chirp:        database.Chirp{ID: uuid.New()},
+		PollClosesAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true},
+	}
+	closingPoll := database.ListDueChirpsForUpdateRow{
+		Chirp:        database.Chirp{ID: uuid.New()},
+		PollClosesAt: sql.NullTime{Time: now, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		rows           []database.ListDueChirpsForUpdateRow
+		wantPublish    []uuid.UUID
+		wantUnschedule []uuid.UUID
+	}{
+		{
+			name:        "Chirp without poll",
+			rows:        []database.ListDueChirpsForUpdateRow{withoutPoll},
+			wantPublish: []uuid.UUID{withoutPoll.Chirp.ID},
+		},
+		{
+			name:        "Open poll",
+			rows:        []database.ListDueChirpsForUpdateRow{openPoll},
+			wantPublish: []uuid.UUID{openPoll.Chirp.ID},
+		},
+		{
+			name:           "Closed poll",
+			rows:           []database.ListDueChirpsForUpdateRow{closedPoll, closingPoll},
+			wantUnschedule: []uuid.UUID{closedPoll.Chirp.ID, closingPoll.Chirp.ID},
+		},
+		{
+			name:           "Mixed batch",
+			rows:           []database.ListDueChirpsForUpdateRow{closedPoll, withoutPoll, openPoll},
+			wantPublish:    []uuid.UUID{withoutPoll.Chirp.ID, openPoll.Chirp.ID},
+			wantUnschedule: []uuid.UUID{closedPoll.Chirp.ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publish, unschedule := splitDueChirps(tt.rows, now)
+			if !slices.Equal(publish, tt.wantPublish) {
+				t.Errorf("splitDueChirps() publish = %v, want %v", publish, tt.wantPublish)
+			}
+			if !slices.Equal(unschedule, tt.wantUnschedule) {
+				t.Errorf("splitDueChirps() unschedule = %v, want %v", unschedule, tt.wantUnschedule)
+			}
+		})
+	}
+}
+
+func TestPublishChirpPollWindow(t *testing.T) {
+	const tokenSecret = "secret"
+	userID := uuid.New()
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Body:      "Which one?",
+		UserID:    userID,
+		Kind:      "chirp",
+		Status:    chirpStatusDraft,
+	}
+	closesAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		body     string
+		closesAt time.Time
+	}{
+		{
+			name:     "Publish with a closed poll",
+			closesAt: time.Now().Add(-time.Hour).UTC(),
+		},
+		{
+			name:     "Schedule after the poll closes",
+			body:     `{"publish_at": "` + closesAt.Add(time.Hour).Format(time.RFC3339) + `"}`,
+			closesAt: closesAt,
+		},
+		{
+			name:     "Schedule when the poll closes",
+			body:     `{"publish_at": "` + closesAt.Format(time.RFC3339) + `"}`,
+			closesAt: closesAt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			fake.on("FindChirpByIdForUpdate", modelResult(chirp))
+			fake.on("FindPollByChirpId", modelResult(database.Poll{ChirpID: chirp.ID, CreatedAt: chirp.CreatedAt, ClosesAt: tt.closesAt}))
+			ac := &apiConfig{db: db, dbQueries: database.New(db), tokenSecret: tokenSecret}
+
+			token, err := auth.MakeJWT(userID, tokenSecret, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/chirps/{id}/publish", ac.handlerPublishChirp)
+			req := httptest.NewRequest(http.MethodPost, "/api/chirps/"+chirp.ID.String()+"/publish", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+			if fake.called("UpdateChirpSchedule") || fake.called("PublishChirp") {
+				t.Errorf("chirp was updated despite its poll")
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxSearchQueryLength = 256
+	maxSearchOffset      = 1000
+)
+
+// handlerSearchChirps runs a full-text search over chirp bodies. The `q`
+// parameter accepts web search syntax: "quoted phrases", `or` and -negation.
+// Results are ranked by relevance and paged with `offset`, or ordered by
+// recency and paged with cursors like the other chirp listings.
+func (ac *apiConfig) handlerSearchChirps(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	searchQuery := query.Get("q")
+	if searchQuery == "" {
+		respondWithError(rw, http.StatusBadRequest, "Search query not specified", nil)
+		return
+	}
+	if len(searchQuery) > maxSearchQueryLength {
+		respondWithError(rw, http.StatusBadRequest, "Search query is too long", nil)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if authorIDString := query.Get("author_id"); authorIDString != "" {
+		authorID.UUID, err = uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(rw, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+		authorID.Valid = true
+	}
+	since, err := parseTimeQueryParam(query.Get("since"))
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid 'since' date. Must be in RFC 3339 format", err)
+		return
+	}
+	until, err := parseTimeQueryParam(query.Get("until"))
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid 'until' date. Must be in RFC 3339 format", err)
+		return
+	}
+
+	page, err := parsePageRequest(query, "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirps []database.Chirp
+	var links pageLinks
+	order := query.Get("order")
+	switch order {
+	case "", "relevance":
+		if page.Cursor != nil {
+			respondWithError(rw, http.StatusBadRequest, "Cursors are only supported when ordering by recency", nil)
+			return
+		}
+		offset := 0
+		if offsetString := query.Get("offset"); offsetString != "" {
+			offset, err = strconv.Atoi(offsetString)
+			if err != nil || offset < 0 || offset > maxSearchOffset {
+				respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid offset '%s'. Must be between 0 and %d", offsetString, maxSearchOffset), err)
+				return
+			}
+		}
+		chirps, err = ac.dbQueries.SearchChirpsByRelevance(req.Context(), database.SearchChirpsByRelevanceParams{
+			Query:      searchQuery,
+			AuthorID:   authorID,
+			Since:      since,
+			Until:      until,
+			ViewerID:   viewerID,
+			PageSize:   page.fetchLimit(),
+			PageOffset: int32(offset),
+		})
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to search chirps", err)
+			return
+		}
+		if len(chirps) > int(page.Limit) {
+			chirps = chirps[:page.Limit]
+			nextQuery := req.URL.Query()
+			nextQuery.Set("offset", strconv.Itoa(offset+int(page.Limit)))
+			rw.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, nextQuery.Encode()))
+		}
+	case "recency":
+		cursorCreatedAt, cursorID := page.cursorArgs()
+		if page.ascending() {
+			chirps, err = ac.dbQueries.SearchChirpsAsc(req.Context(), database.SearchChirpsAscParams{
+				Query:           searchQuery,
+				AuthorID:        authorID,
+				Since:           since,
+				Until:           until,
+				ViewerID:        viewerID,
+				CursorCreatedAt: cursorCreatedAt,
+				CursorID:        cursorID,
+				PageSize:        page.fetchLimit(),
+			})
+		} else {
+			chirps, err = ac.dbQueries.SearchChirpsDesc(req.Context(), database.SearchChirpsDescParams{
+				Query:           searchQuery,
+				AuthorID:        authorID,
+				Since:           since,
+				Until:           until,
+				ViewerID:        viewerID,
+				CursorCreatedAt: cursorCreatedAt,
+				CursorID:        cursorID,
+				PageSize:        page.fetchLimit(),
+			})
+		}
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Failed to search chirps", err)
+			return
+		}
+		chirps, links = paginate(page, chirps, chirpCursor)
+		setPaginationLinks(rw, req, links)
+	default:
+		respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid order '%s'. Must be either 'relevance' or 'recency'", order), nil)
+		return
+	}
+
+	response, err := ac.buildChirpInfos(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to search chirps", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+func parseTimeQueryParam(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: parsed, Valid: true}, nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxUserAgentLength bounds the user agent stored with a session, as it
+	// is provided by the client.
+	maxUserAgentLength = 512
+	// revokedSessionRetention is how long signed out sessions are kept, so
+	// that they still show up in data exports for a while.
+	revokedSessionRetention = 7 * 24 * time.Hour
+	sessionPurgeInterval    = time.Hour
+	sessionPurgeBatch       = 500
+	// refreshTokenReuseGrace is how long after a refresh token was exchanged
+	// presenting it again is taken for a client retrying or racing itself,
+	// and is only rejected instead of revoking the session.
+	refreshTokenReuseGrace = 10 * time.Second
+)
+
+var (
+	errRefreshTokenExpired = errors.New("refresh token expired")
+	errRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// sessionInfo describes a session to its owner, from the current refresh
+// token of the session. Tokens themselves are never shown again.
+type sessionInfo struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+}
+
+// sessionMetadata returns the user agent and IP address of the client to
+// record with a new session.
+func sessionMetadata(req *http.Request) (string, string) {
+	userAgent := strings.ToValidUTF8(req.UserAgent(), "")
+	if len(userAgent) > maxUserAgentLength {
+		// Cut at a rune boundary so the stored text stays valid UTF-8.
+		end := maxUserAgentLength
+		for end > 0 && !utf8.RuneStart(userAgent[end]) {
+			end--
+		}
+		userAgent = userAgent[:end]
+	}
+	ipAddress, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ipAddress = req.RemoteAddr
+	}
+	return userAgent, ipAddress
+}
+
+// rotateRefreshToken exchanges a refresh token for a new one in the same
+// session, which keeps the expiry of the session. Replaying a token that
+// was already exchanged means it leaked, so the whole session is revoked,
+// unless it was exchanged within refreshTokenReuseGrace.
+func (ac *apiConfig) rotateRefreshToken(req *http.Request, refreshToken string) (database.RefreshToken, string, error) {
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return database.RefreshToken{}, "", err
+	}
+	tokenHash := auth.HashToken(refreshToken)
+	newTokenHash := auth.HashToken(newRefreshToken)
+	userAgent, ipAddress := sessionMetadata(req)
+
+	var current database.RefreshToken
+	reused := false
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		var err error
+		current, err = qtx.FindRefreshTokenForUpdate(req.Context(), tokenHash)
+		if err != nil {
+			return err
+		}
+		if current.RevokedAt.Valid {
+			if !current.ReplacedBy.Valid || inRefreshTokenReuseGrace(current, time.Now()) {
+				return sql.ErrNoRows
+			}
+			// The reuse is recorded, so the changes must be committed.
+			reused = true
+			if err := qtx.FlagRefreshTokenReuse(req.Context(), tokenHash); err != nil {
+				return err
+			}
+			_, err := qtx.RevokeSession(req.Context(), database.RevokeSessionParams{
+				UserID:    current.UserID,
+				SessionID: current.SessionID,
+			})
+			return err
+		}
+		if current.ExpiresAt.Before(time.Now()) {
+			return errRefreshTokenExpired
+		}
+		_, err = qtx.CreateRotatedRefreshToken(req.Context(), database.CreateRotatedRefreshTokenParams{
+			TokenHash:        newTokenHash,
+			UserID:           current.UserID,
+			ExpiresAt:        current.ExpiresAt,
+			SessionID:        current.SessionID,
+			SessionStartedAt: current.SessionStartedAt,
+			UserAgent:        userAgent,
+			IpAddress:        ipAddress,
+		})
+		if err != nil {
+			return err
+		}
+		return qtx.ReplaceRefreshToken(req.Context(), database.ReplaceRefreshTokenParams{
+			TokenHash:  tokenHash,
+			ReplacedBy: sql.NullString{String: newTokenHash, Valid: true},
+		})
+	})
+	if err != nil {
+		return database.RefreshToken{}, "", err
+	}
+	if reused {
+		log.Printf("Refresh token reuse detected for session %s of user %s, revoked the session\n", current.SessionID, current.UserID)
+		return database.RefreshToken{}, "", errRefreshTokenReused
+	}
+	return current, newRefreshToken, nil
+}
+
+// inRefreshTokenReuseGrace reports whether the revoked token was exchanged
+// for a new one recently enough at now for its reuse to be tolerated.
+func inRefreshTokenReuseGrace(token database.RefreshToken, now time.Time) bool {
+	return token.ReplacedBy.Valid && token.RevokedAt.Valid && now.Sub(token.RevokedAt.Time) < refreshTokenReuseGrace
+}
+
+// handlerListSessions lists the sessions of the authenticated user that can
+// still be refreshed, most recently used first.
+func (ac *apiConfig) handlerListSessions(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	sessions, err := ac.dbQueries.ListActiveSessions(req.Context(), userID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get sessions", err)
+		return
+	}
+	response := make([]sessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionInfo{
+			ID:         session.SessionID,
+			CreatedAt:  session.SessionStartedAt,
+			LastUsedAt: nullTimePtr(session.LastUsedAt),
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IpAddress,
+		})
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerRevokeSession signs a session of the authenticated user out. Its
+// access tokens stay valid until they expire.
+func (ac *apiConfig) handlerRevokeSession(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	sessionID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid session ID", err)
+		return
+	}
+
+	revoked, err := ac.dbQueries.RevokeSession(req.Context(), database.RevokeSessionParams{
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to revoke session", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Session %s not found", sessionID), nil)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRevokeAllSessions signs the authenticated user out everywhere,
+// including the session making the request.
+func (ac *apiConfig) handlerRevokeAllSessions(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	if err := ac.dbQueries.RevokeUserRefreshTokens(req.Context(), userID); err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to revoke sessions", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// purgeStaleRefreshTokens deletes the refresh tokens of sessions that
+// expired or were signed out a while ago.
+func (ac *apiConfig) purgeStaleRefreshTokens(ctx context.Context) {
+	for {
+		purged, err := ac.dbQueries.PurgeStaleRefreshTokens(ctx, database.PurgeStaleRefreshTokensParams{
+			RevokedBefore: sql.NullTime{Time: time.Now().Add(-revokedSessionRetention), Valid: true},
+			MaxTokens:     sessionPurgeBatch,
+		})
+		if err != nil {
+			log.Printf("Failed to purge stale refresh tokens: %s\n", err)
+			return
+		}
+		if purged > 0 {
+			log.Printf("Purged %d stale refresh tokens\n", purged)
+		}
+		if purged < sessionPurgeBatch {
+			return
+		}
+	}
+}
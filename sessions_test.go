@@ -0,0 +1,114 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		wantLen   int
+	}{
+		{
+			name:      "Short user agent",
+			userAgent: "curl/8.5.0",
+			wantLen:   len("curl/8.5.0"),
+		},
+		{
+			name:      "Long ASCII user agent",
+			userAgent: strings.Repeat("a", maxUserAgentLength+10),
+			wantLen:   maxUserAgentLength,
+		},
+		{
+			name:      "Multibyte rune across the limit",
+			userAgent: strings.Repeat("a", maxUserAgentLength-1) + "é",
+			wantLen:   maxUserAgentLength - 1,
+		},
+		{
+			name:      "Invalid UTF-8",
+			userAgent: "agent\xff",
+			wantLen:   len("agent"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/login", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			req.RemoteAddr = "192.0.2.1:1234"
+
+			userAgent, ipAddress := sessionMetadata(req)
+			if !utf8.ValidString(userAgent) {
+				t.Fatalf("sessionMetadata() returned invalid UTF-8 %q", userAgent)
+			}
+			if len(userAgent) != tt.wantLen {
+				t.Errorf("sessionMetadata() user agent length expected = %d, actual = %d", tt.wantLen, len(userAgent))
+			}
+			if ipAddress != "192.0.2.1" {
+				t.Errorf("sessionMetadata() IP address expected = 192.0.2.1, actual = %s", ipAddress)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	tests := []struct {
+		name            string
+		exchangedAgo    time.Duration
+		wantSessionDrop bool
+	}{
+		{
+			name:         "Reused within the grace period",
+			exchangedAgo: time.Second,
+		},
+		{
+			name:            "Reused after the grace period",
+			exchangedAgo:    time.Minute,
+			wantSessionDrop: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().UTC()
+			token := database.RefreshToken{
+				TokenHash:        "old",
+				CreatedAt:        now.Add(-time.Hour),
+				UpdatedAt:        now.Add(-tt.exchangedAgo),
+				UserID:           uuid.New(),
+				ExpiresAt:        now.Add(time.Hour),
+				RevokedAt:        sql.NullTime{Time: now.Add(-tt.exchangedAgo), Valid: true},
+				SessionID:        uuid.New(),
+				SessionStartedAt: now.Add(-time.Hour),
+				ReplacedBy:       sql.NullString{String: "new", Valid: true},
+			}
+			fake, db := newFakeDB(t)
+			fake.on("FindRefreshTokenForUpdate", modelResult(token))
+			fake.on("FlagRefreshTokenReuse", fakeResult{})
+			fake.on("RevokeSession", fakeResult{})
+			ac := &apiConfig{db: db, dbQueries: database.New(db)}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/refresh", nil)
+			req.Header.Set("Authorization", "Bearer old-token")
+			ac.handlerRefreshToken(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			if got := fake.called("RevokeSession"); got != tt.wantSessionDrop {
+				t.Errorf("session revoked = %v, want %v", got, tt.wantSessionDrop)
+			}
+		})
+	}
+}
@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
@@ -13,14 +14,51 @@ RETURNING *;
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
 
+-- name: DeleteUser :execrows
+-- Everything the user owns is deleted along with them.
+DELETE FROM users WHERE id = $1;
+
 -- name: FindUserByEmail :one
 SELECT * FROM users WHERE email = $1 LIMIT 1;
 
 -- name: FindUserById :one
 SELECT * FROM users WHERE id = $1 LIMIT 1;
 
--- name: UpdateUserCredentials :one
-UPDATE users SET email = $1, hashed_password = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at;
+-- name: FindUserByIdForUpdate :one
+SELECT * FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: FindUserByHandle :one
+SELECT * FROM users WHERE handle = $1;
+
+-- name: FindUsersByHandles :many
+SELECT * FROM users WHERE handle = ANY(sqlc.arg(handles)::text[]);
+
+-- name: UpdateUserProfile :one
+-- Fields passed as NULL are left unchanged. Profile fields passed as an
+-- empty string are cleared.
+UPDATE users SET
+    handle = COALESCE(sqlc.narg(handle), handle),
+    display_name = CASE WHEN sqlc.narg(display_name)::text IS NULL THEN display_name ELSE NULLIF(sqlc.narg(display_name), '') END,
+    bio = CASE WHEN sqlc.narg(bio)::text IS NULL THEN bio ELSE NULLIF(sqlc.narg(bio), '') END,
+    avatar_url = CASE WHEN sqlc.narg(avatar_url)::text IS NULL THEN avatar_url ELSE NULLIF(sqlc.narg(avatar_url), '') END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateUserEmail :one
+-- The new email has been confirmed through a token sent to it.
+UPDATE users SET email = $1, email_verified = true, updated_at = NOW() WHERE id = $2 RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = $1, updated_at = NOW() WHERE id = $2;
+
+-- name: VerifyUserEmail :one
+-- Verification only applies if the email has not changed since the token
+-- was sent.
+UPDATE users SET email_verified = true, updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING *;
 
 -- name: UpdateUserToChirpyRed :execrows
 UPDATE users SET is_chirpy_red = true, updated_at = NOW() WHERE id = $1;
+
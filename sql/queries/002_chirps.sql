@@ -1,22 +1,164 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, status, publish_at)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
--- name: ListAllChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+-- name: CreateRechirp :one
+-- Creating a plain rechirp twice is a no-op that returns no rows.
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, rechirp_of)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, rechirp_of) WHERE kind = 'rechirp' AND deleted_at IS NULL DO NOTHING
+RETURNING *;
 
--- name: ListAllChirpsForUser :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
+-- name: FindChirpByIdForUpdate :one
+SELECT * FROM chirps WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+-- Only changes to published chirps count as edits.
+UPDATE chirps
+SET body = $1, edited_at = CASE WHEN status = 'published' THEN NOW() ELSE edited_at END, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: FindChirpsByIds :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND deleted_at IS NULL;
+
+-- name: ListChirpsAsc :many
+-- Chirps of users the viewer blocked, muted or was blocked by are left out,
+-- as well as the excluded chirps.
+SELECT * FROM chirps
+WHERE status = 'published' AND deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND id <> ALL(COALESCE(sqlc.narg(excluded_ids)::uuid[], '{}'))
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE status = 'published' AND deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND id <> ALL(COALESCE(sqlc.narg(excluded_ids)::uuid[], '{}'))
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
 
 -- name: FindChirpById :one
-SELECT * FROM chirps WHERE id = $1;
+SELECT * FROM chirps WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: FindPublishedChirpById :one
+SELECT * FROM chirps WHERE id = $1 AND status = 'published' AND deleted_at IS NULL;
+
+-- name: SoftDeleteChirpById :exec
+-- Plain rechirps are moved to the trash along with the chirp they share.
+UPDATE chirps SET deleted_at = NOW()
+WHERE (id = $1 OR (rechirp_of = $1 AND kind = 'rechirp')) AND deleted_at IS NULL;
+
+-- name: CountRepliesForChirps :many
+SELECT in_reply_to, COUNT(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg(chirp_ids)::uuid[]) AND status = 'published' AND deleted_at IS NULL
+GROUP BY in_reply_to;
+
+-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to, depth) AS (
+    SELECT parent.id, parent.in_reply_to, 1
+    FROM chirps AS child
+    JOIN chirps AS parent ON parent.id = child.in_reply_to
+    WHERE child.id = $1
+    UNION ALL
+    SELECT chirps.id, chirps.in_reply_to, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.in_reply_to
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE chirps.deleted_at IS NULL
+ORDER BY ancestors.depth DESC;
+
+-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants (id, depth) AS (
+    SELECT chirps.id, 1
+    FROM chirps
+    WHERE chirps.in_reply_to = sqlc.arg(chirp_id) AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+    FROM chirps
+    JOIN descendants ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < sqlc.arg(max_depth)::int AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+)
+SELECT chirps.* FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg(max_chirps);
+
+-- name: ListUserChirpsByStatusAsc :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id) AND status = sqlc.arg(status) AND deleted_at IS NULL
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListUserChirpsByStatusDesc :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id) AND status = sqlc.arg(status) AND deleted_at IS NULL
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: UpdateChirpSchedule :one
+UPDATE chirps SET status = $1, publish_at = $2, updated_at = NOW() WHERE id = $3 RETURNING *;
+
+-- name: PublishChirp :one
+-- Publishing moves the chirp to the top of the listings.
+UPDATE chirps SET status = 'published', created_at = NOW(), updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: ListDueChirpsForUpdate :many
+-- Rows locked by a concurrent run, possibly on another server instance, are
+-- skipped, so every chirp is handled exactly once.
+SELECT sqlc.embed(chirps), polls.closes_at AS poll_closes_at FROM chirps
+LEFT JOIN polls ON polls.chirp_id = chirps.id
+WHERE chirps.status = 'scheduled' AND chirps.publish_at <= NOW() AND chirps.deleted_at IS NULL
+ORDER BY chirps.publish_at
+LIMIT sqlc.arg(max_chirps)
+FOR UPDATE OF chirps SKIP LOCKED;
+
+-- name: PublishChirps :execrows
+UPDATE chirps SET status = 'published', created_at = NOW(), updated_at = NOW()
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: UnscheduleChirps :execrows
+UPDATE chirps SET status = 'draft', publish_at = NULL, updated_at = NOW()
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
 
--- name: DeleteChirpById :exec
-DELETE FROM chirps WHERE id = $1;
+-- name: ListAllUserChirps :many
+-- Includes drafts, scheduled chirps and chirps in the trash.
+SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at, id;
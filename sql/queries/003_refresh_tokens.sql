@@ -1,22 +1,103 @@
 -- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens(
-    token,
+    token_hash,
     created_at,
     updated_at,
     user_id,
-    expires_at
+    expires_at,
+    session_id,
+    user_agent,
+    ip_address
 )
 VALUES(
     $1,
     NOW(),
     NOW(),
     $2,
-    $3
+    $3,
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
 
 -- name: FindRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token = $1 AND revoked_at IS NULL LIMIT 1;
+SELECT * FROM refresh_tokens WHERE token_hash = $1 AND revoked_at IS NULL LIMIT 1;
+
+-- name: FindRefreshTokenForUpdate :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE;
+
+-- name: CreateRotatedRefreshToken :one
+INSERT INTO refresh_tokens(
+    token_hash,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at,
+    session_id,
+    session_started_at,
+    user_agent,
+    ip_address,
+    last_used_at
+)
+VALUES(
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    NOW()
+)
+RETURNING *;
+
+-- name: ReplaceRefreshToken :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW(), replaced_by = $2
+WHERE token_hash = $1;
+
+-- name: FlagRefreshTokenReuse :exec
+UPDATE refresh_tokens SET reuse_detected_at = NOW(), updated_at = NOW() WHERE token_hash = $1;
 
 -- name: RevokeRefreshToken :exec
-UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1;
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token_hash = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND session_id = $2 AND revoked_at IS NULL;
+
+-- name: ListActiveSessions :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY COALESCE(last_used_at, created_at) DESC, session_id;
+
+-- name: ListUserSessions :many
+-- Returns the latest token of each session of the user, which describes the
+-- session as a whole.
+SELECT * FROM (
+    SELECT DISTINCT ON (session_id) * FROM refresh_tokens
+    WHERE user_id = $1
+    ORDER BY session_id, created_at DESC
+) AS sessions
+ORDER BY session_started_at, session_id;
+
+-- name: PurgeStaleRefreshTokens :execrows
+-- Rotated tokens are kept as long as their session can be refreshed, so that
+-- their replay is detected. Sessions are deleted once expired, or once signed
+-- out for long enough.
+DELETE FROM refresh_tokens
+WHERE token_hash IN (
+    SELECT token_hash FROM refresh_tokens AS stale
+    WHERE stale.expires_at < NOW()
+        OR (stale.revoked_at < sqlc.arg(revoked_before) AND NOT EXISTS (
+            SELECT 1 FROM refresh_tokens AS live
+            WHERE live.session_id = stale.session_id AND live.revoked_at IS NULL
+        ))
+    LIMIT sqlc.arg(max_tokens)
+    FOR UPDATE SKIP LOCKED
+);
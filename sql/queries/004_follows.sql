@@ -0,0 +1,75 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: CountFollows :one
+SELECT
+    (SELECT COUNT(*) FROM follows WHERE followee_id = sqlc.arg(user_id)) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = sqlc.arg(user_id)) AS following_count;
+
+-- name: ListFollowersAsc :many
+SELECT * FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, follower_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, follower_id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListFollowersDesc :many
+SELECT * FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, follower_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListFolloweesAsc :many
+SELECT * FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, followee_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, followee_id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListFolloweesDesc :many
+SELECT * FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, followee_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListTimelineAsc :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+            OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListTimelineDesc :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+            OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg(user_id) AND followee_id = sqlc.arg(other_id))
+    OR (follower_id = sqlc.arg(other_id) AND followee_id = sqlc.arg(user_id));
@@ -0,0 +1,46 @@
+-- name: CreateLike :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteLike :execrows
+DELETE FROM likes WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountLikesForChirps :many
+SELECT chirp_id, COUNT(*) AS like_count FROM likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: ListLikedChirpIDs :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: ListLikedChirpsAsc :many
+SELECT sqlc.embed(chirps), likes.created_at AS liked_at FROM chirps
+JOIN likes ON likes.chirp_id = chirps.id
+WHERE likes.user_id = sqlc.arg(user_id)
+    AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (likes.created_at, likes.chirp_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY likes.created_at, likes.chirp_id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListLikedChirpsDesc :many
+SELECT sqlc.embed(chirps), likes.created_at AS liked_at FROM chirps
+JOIN likes ON likes.chirp_id = chirps.id
+WHERE likes.user_id = sqlc.arg(user_id)
+    AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (likes.created_at, likes.chirp_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY likes.created_at DESC, likes.chirp_id DESC
+LIMIT sqlc.arg(page_size);
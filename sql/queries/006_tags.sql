@@ -0,0 +1,54 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, name, created_at)
+VALUES (gen_random_uuid(), $1, NOW())
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: CreateChirpTag :exec
+INSERT INTO chirp_tags (chirp_id, tag_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags WHERE chirp_id = $1;
+
+-- name: ListTagChirpsAsc :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE tags.name = sqlc.arg(tag)
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListTagChirpsDesc :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE tags.name = sqlc.arg(tag)
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListTrendingTags :many
+SELECT tags.name, COUNT(*) AS chirp_count FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirps.status = 'published' AND chirps.deleted_at IS NULL AND chirps.created_at >= sqlc.arg(since)
+GROUP BY tags.name
+ORDER BY chirp_count DESC, tags.name
+LIMIT sqlc.arg(max_tags);
@@ -0,0 +1,42 @@
+-- name: CreateMention :exec
+INSERT INTO mentions (chirp_id, user_id, start_offset, end_offset, created_at)
+VALUES ($1, $2, $3, $4, NOW());
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions WHERE chirp_id = $1;
+
+-- name: ListMentionsForChirps :many
+SELECT mentions.chirp_id, mentions.user_id, users.handle, mentions.start_offset, mentions.end_offset FROM mentions
+JOIN users ON users.id = mentions.user_id
+WHERE mentions.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY mentions.chirp_id, mentions.start_offset;
+
+-- name: ListMentioningChirpsAsc :many
+SELECT * FROM chirps
+WHERE id IN (SELECT mentions.chirp_id FROM mentions WHERE mentions.user_id = sqlc.arg(user_id))
+    AND user_id <> sqlc.arg(user_id)
+    AND status = 'published' AND deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+            OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListMentioningChirpsDesc :many
+SELECT * FROM chirps
+WHERE id IN (SELECT mentions.chirp_id FROM mentions WHERE mentions.user_id = sqlc.arg(user_id))
+    AND user_id <> sqlc.arg(user_id)
+    AND status = 'published' AND deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+            OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
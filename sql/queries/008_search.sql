@@ -0,0 +1,49 @@
+-- name: SearchChirpsByRelevance :many
+SELECT * FROM chirps
+WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg(query))
+    AND status = 'published' AND deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+ORDER BY ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', sqlc.arg(query))) DESC, created_at DESC, id DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: SearchChirpsAsc :many
+SELECT * FROM chirps
+WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg(query))
+    AND status = 'published' AND deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: SearchChirpsDesc :many
+SELECT * FROM chirps
+WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg(query))
+    AND status = 'published' AND deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id))
+            OR (blocks.blocker_id = sqlc.narg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
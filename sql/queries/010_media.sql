@@ -0,0 +1,31 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, storage_key, content_type, size_bytes)
+VALUES ($1, NOW(), $2, $3, $4, $5)
+RETURNING *;
+
+-- name: AttachMediaToChirp :execrows
+-- Only media uploaded by the chirp's author and not yet attached to another
+-- chirp can be attached.
+UPDATE media SET chirp_id = sqlc.arg(chirp_id), position = sqlc.arg(position)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND chirp_id IS NULL;
+
+-- name: ListMediaForChirps :many
+SELECT * FROM media WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]) ORDER BY chirp_id, position;
+
+-- name: ListMediaByUser :many
+SELECT * FROM media WHERE user_id = $1 ORDER BY created_at, id;
+
+-- name: DeleteMediaForChirps :many
+DELETE FROM media WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+RETURNING storage_key;
+
+-- name: DeleteUnattachedMedia :many
+-- Uploads never attached to a chirp are deleted once they are old enough.
+DELETE FROM media
+WHERE id IN (
+    SELECT id FROM media
+    WHERE chirp_id IS NULL AND created_at < sqlc.arg(created_before)
+    LIMIT sqlc.arg(max_media)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING storage_key;
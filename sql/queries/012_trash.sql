@@ -0,0 +1,37 @@
+-- name: ListTrashAsc :many
+-- Plain rechirps trashed along with the chirp they share cannot be restored
+-- on their own and are left out.
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id) AND deleted_at > sqlc.arg(deleted_since)
+    AND NOT (kind = 'rechirp' AND rechirp_of IN (SELECT id FROM chirps AS originals WHERE originals.deleted_at IS NOT NULL))
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListTrashDesc :many
+-- Plain rechirps trashed along with the chirp they share cannot be restored
+-- on their own and are left out.
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id) AND deleted_at > sqlc.arg(deleted_since)
+    AND NOT (kind = 'rechirp' AND rechirp_of IN (SELECT id FROM chirps AS originals WHERE originals.deleted_at IS NOT NULL))
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: FindDeletedChirpByIdForUpdate :one
+SELECT * FROM chirps WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE;
+
+-- name: RestoreChirp :exec
+-- Plain rechirps trashed at the same time as the chirp are restored too.
+UPDATE chirps SET deleted_at = NULL
+WHERE id = sqlc.arg(id) OR (rechirp_of = sqlc.arg(id) AND kind = 'rechirp' AND deleted_at = sqlc.arg(deleted_at));
+
+-- name: ListPurgeableChirpIDs :many
+-- Rows locked by a concurrent purge are skipped.
+SELECT id FROM chirps
+WHERE deleted_at < sqlc.arg(deleted_before)
+LIMIT sqlc.arg(max_chirps)
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteChirpsByIds :execrows
+DELETE FROM chirps WHERE id = ANY(sqlc.arg(ids)::uuid[]);
@@ -0,0 +1,67 @@
+-- name: CreateCollection :one
+INSERT INTO collections (id, created_at, updated_at, user_id, name)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+RETURNING *;
+
+-- name: FindCollectionById :one
+SELECT * FROM collections WHERE id = $1;
+
+-- name: RenameCollection :one
+UPDATE collections SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING *;
+
+-- name: DeleteCollection :exec
+DELETE FROM collections WHERE id = $1;
+
+-- name: ListCollectionsAsc :many
+SELECT * FROM collections
+WHERE user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListCollectionsDesc :many
+SELECT * FROM collections
+WHERE user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: AddChirpToCollection :execrows
+INSERT INTO collection_chirps (collection_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveChirpFromCollection :execrows
+DELETE FROM collection_chirps WHERE collection_id = $1 AND chirp_id = $2;
+
+-- name: ListCollectionChirpsAsc :many
+SELECT sqlc.embed(chirps), collection_chirps.created_at AS added_at FROM chirps
+JOIN collection_chirps ON collection_chirps.chirp_id = chirps.id
+JOIN collections ON collections.id = collection_chirps.collection_id
+WHERE collection_chirps.collection_id = sqlc.arg(collection_id)
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = collections.user_id)
+            OR (blocks.blocker_id = collections.user_id AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = collections.user_id AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (collection_chirps.created_at, collection_chirps.chirp_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY collection_chirps.created_at, collection_chirps.chirp_id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListCollectionChirpsDesc :many
+SELECT sqlc.embed(chirps), collection_chirps.created_at AS added_at FROM chirps
+JOIN collection_chirps ON collection_chirps.chirp_id = chirps.id
+JOIN collections ON collections.id = collection_chirps.collection_id
+WHERE collection_chirps.collection_id = sqlc.arg(collection_id)
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = collections.user_id)
+            OR (blocks.blocker_id = collections.user_id AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = collections.user_id AND mutes.muted_id = chirps.user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (collection_chirps.created_at, collection_chirps.chirp_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY collection_chirps.created_at DESC, collection_chirps.chirp_id DESC
+LIMIT sqlc.arg(page_size);
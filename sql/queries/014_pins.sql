@@ -0,0 +1,25 @@
+-- name: CreatePin :exec
+INSERT INTO pins (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePin :execrows
+DELETE FROM pins WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountOtherPins :one
+-- Counts the chirps pinned by the user other than the given one, so that
+-- pinning a chirp again does not count against the limit. Pins of chirps in
+-- the trash are kept in case they are restored, but do not count either.
+SELECT COUNT(*) FROM pins
+JOIN chirps ON chirps.id = pins.chirp_id
+WHERE pins.user_id = $1 AND pins.chirp_id <> $2
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL;
+
+-- name: ListPinnedChirps :many
+SELECT chirps.* FROM chirps
+JOIN pins ON pins.chirp_id = chirps.id
+WHERE pins.user_id = $1 AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+ORDER BY pins.created_at DESC, pins.chirp_id DESC;
+
+-- name: ListPinnedChirpIDs :many
+SELECT chirp_id FROM pins WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
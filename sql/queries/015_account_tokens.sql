@@ -0,0 +1,20 @@
+-- name: CreateAccountToken :exec
+INSERT INTO account_tokens (token_hash, created_at, user_id, purpose, email, expires_at)
+VALUES ($1, NOW(), $2, $3, $4, $5);
+
+-- name: InvalidateAccountTokens :exec
+-- Requesting a new token for a purpose invalidates the previous ones.
+UPDATE account_tokens SET used_at = NOW()
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
+
+-- name: ConsumeAccountToken :one
+-- Tokens can only be used once, before they expire.
+UPDATE account_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: HasRecentAccountToken :one
+SELECT EXISTS (
+    SELECT 1 FROM account_tokens
+    WHERE user_id = sqlc.arg(user_id) AND purpose = sqlc.arg(purpose) AND created_at > sqlc.arg(created_after)
+) AS recent;
@@ -0,0 +1,60 @@
+-- name: CreateBlock :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlockedBetween :one
+-- Reports whether either user has blocked the other.
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = sqlc.arg(other_id))
+        OR (blocker_id = sqlc.arg(other_id) AND blocked_id = sqlc.arg(user_id))
+) AS blocked;
+
+-- name: ListBlocksAsc :many
+SELECT * FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, blocked_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, blocked_id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListBlocksDesc :many
+SELECT * FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, blocked_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CreateMute :execrows
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: ListMutesAsc :many
+SELECT * FROM mutes
+WHERE muter_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, muted_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at, muted_id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListMutesDesc :many
+SELECT * FROM mutes
+WHERE muter_id = sqlc.arg(user_id)
+    AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, muted_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListHiddenUserIDs :many
+-- Returns the users whose chirps are hidden from the given user: the users
+-- they blocked or muted and the users who blocked them.
+SELECT blocked_id AS user_id FROM blocks WHERE blocker_id = sqlc.arg(user_id)
+UNION
+SELECT blocker_id AS user_id FROM blocks WHERE blocked_id = sqlc.arg(user_id)
+UNION
+SELECT muted_id AS user_id FROM mutes WHERE muter_id = sqlc.arg(user_id);
@@ -0,0 +1,61 @@
+-- name: UpsertTOTPCredential :execrows
+-- Starting an enrollment replaces a pending one, but never a confirmed one.
+INSERT INTO totp_credentials (user_id, created_at, secret)
+VALUES ($1, NOW(), $2)
+ON CONFLICT (user_id) DO UPDATE SET created_at = NOW(), secret = EXCLUDED.secret, last_used_counter = 0
+WHERE totp_credentials.confirmed_at IS NULL;
+
+-- name: FindTOTPCredentialForUpdate :one
+SELECT * FROM totp_credentials WHERE user_id = $1 FOR UPDATE;
+
+-- name: IsTOTPEnabled :one
+SELECT EXISTS (
+    SELECT 1 FROM totp_credentials WHERE user_id = $1 AND confirmed_at IS NOT NULL
+) AS enabled;
+
+-- name: ConfirmTOTPCredential :exec
+UPDATE totp_credentials SET confirmed_at = NOW(), last_used_counter = $2 WHERE user_id = $1;
+
+-- name: UpdateTOTPLastUsedCounter :exec
+UPDATE totp_credentials SET last_used_counter = $2 WHERE user_id = $1;
+
+-- name: DeleteTOTPCredential :exec
+DELETE FROM totp_credentials WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+VALUES ($1, $2, NOW());
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: ListUnusedRecoveryCodeHashes :many
+SELECT code_hash FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (id, user_id, created_at, expires_at)
+VALUES (gen_random_uuid(), $1, NOW(), $2)
+RETURNING *;
+
+-- name: UseMFAChallengeAttempt :execrows
+-- Counts an attempt at the challenge, unless it was used, expired or ran out
+-- of attempts.
+UPDATE mfa_challenges SET attempts = attempts + 1
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+    AND used_at IS NULL AND expires_at > NOW() AND attempts < sqlc.arg(max_attempts);
+
+-- name: CompleteMFAChallenge :execrows
+UPDATE mfa_challenges SET used_at = NOW() WHERE id = $1 AND used_at IS NULL;
+
+-- name: CountRecentMFAFailures :one
+-- Every attempt but the one completing a challenge failed.
+SELECT COALESCE(SUM(attempts - CASE WHEN used_at IS NULL THEN 0 ELSE 1 END), 0)::bigint AS failures
+FROM mfa_challenges
+WHERE user_id = sqlc.arg(user_id) AND created_at > sqlc.arg(since);
+
+-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM mfa_challenges WHERE expires_at < $1;
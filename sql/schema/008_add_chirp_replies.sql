@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL;
+CREATE INDEX chirps_in_reply_to ON chirps (in_reply_to);
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN in_reply_to;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN kind TEXT NOT NULL DEFAULT 'chirp' CHECK (kind IN ('chirp', 'rechirp', 'quote'));
+ALTER TABLE chirps ADD COLUMN rechirp_of UUID REFERENCES chirps(id) ON DELETE SET NULL;
+CREATE INDEX chirps_rechirp_of ON chirps (rechirp_of);
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of ON chirps (user_id, rechirp_of) WHERE kind = 'rechirp';
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN rechirp_of;
+ALTER TABLE chirps DROP COLUMN kind;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle TEXT UNIQUE CHECK (handle ~ '^[a-z0-9_]{1,30}$');
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN handle;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE mentions(
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+CREATE INDEX mentions_user_id ON mentions (user_id);
+
+-- +goose Down
+DROP TABLE mentions;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE chirps ADD COLUMN publish_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE chirps ADD CONSTRAINT chirps_scheduled_publish_at
+    CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+CREATE INDEX chirps_scheduled_publish_at ON chirps (publish_at) WHERE status = 'scheduled';
+CREATE INDEX chirps_user_id_status_created_at ON chirps (user_id, status, created_at, id);
+
+-- +goose Down
+DROP INDEX chirps_user_id_status_created_at;
+DROP INDEX chirps_scheduled_publish_at;
+ALTER TABLE chirps DROP CONSTRAINT chirps_scheduled_publish_at;
+ALTER TABLE chirps DROP COLUMN publish_at;
+ALTER TABLE chirps DROP COLUMN status;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE collections(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+CREATE INDEX collections_user_id_created_at ON collections (user_id, created_at, id);
+CREATE TABLE collection_chirps(
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (collection_id, chirp_id)
+);
+CREATE INDEX collection_chirps_collection_id_created_at ON collection_chirps (collection_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE collection_chirps;
+DROP TABLE collections;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE pins(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX pins_chirp_id ON pins (chirp_id);
+
+-- +goose Down
+DROP TABLE pins;
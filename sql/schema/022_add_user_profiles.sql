@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN display_name TEXT DEFAULT NULL;
+ALTER TABLE users ADD COLUMN bio TEXT DEFAULT NULL;
+ALTER TABLE users ADD COLUMN avatar_url TEXT DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN avatar_url;
+ALTER TABLE users DROP COLUMN bio;
+ALTER TABLE users DROP COLUMN display_name;
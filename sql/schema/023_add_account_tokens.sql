@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE account_tokens(
+    token_hash TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL
+);
+CREATE INDEX account_tokens_user_id_purpose ON account_tokens (user_id, purpose);
+
+-- +goose Down
+DROP TABLE account_tokens;
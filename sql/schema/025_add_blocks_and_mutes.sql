@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+CREATE INDEX blocks_blocker_id_created_at ON blocks (blocker_id, created_at, blocked_id);
+CREATE INDEX blocks_blocked_id ON blocks (blocked_id, blocker_id);
+
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+CREATE INDEX mutes_muter_id_created_at ON mutes (muter_id, created_at, muted_id);
+
+-- +goose Down
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE totp_credentials(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMPTZ DEFAULT NULL,
+    -- The time step of the last code accepted, so that codes cannot be
+    -- replayed.
+    last_used_counter BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE recovery_codes(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+-- +goose Down
+DROP TABLE recovery_codes;
+DROP TABLE totp_credentials;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Each refresh token is a session. The session ID identifies it to the user
+-- without revealing the token.
+ALTER TABLE refresh_tokens ADD COLUMN session_id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE refresh_tokens ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN last_used_at TIMESTAMPTZ DEFAULT NULL;
+CREATE INDEX refresh_tokens_session_id ON refresh_tokens (session_id);
+CREATE INDEX refresh_tokens_user_id ON refresh_tokens (user_id, created_at);
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id;
+DROP INDEX refresh_tokens_session_id;
+ALTER TABLE refresh_tokens DROP COLUMN last_used_at;
+ALTER TABLE refresh_tokens DROP COLUMN ip_address;
+ALTER TABLE refresh_tokens DROP COLUMN user_agent;
+ALTER TABLE refresh_tokens DROP COLUMN session_id;
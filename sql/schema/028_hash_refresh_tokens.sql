@@ -0,0 +1,10 @@
+-- +goose Up
+-- Refresh tokens are stored as the hex SHA-256 digest of the token, so the
+-- table no longer holds usable credentials. Existing tokens keep working.
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- Digests cannot be turned back into tokens, so every session is signed out.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Refresh tokens are rotated on every use. The tokens of a session form a
+-- family sharing its session ID, each replaced by the next.
+ALTER TABLE refresh_tokens ADD COLUMN session_started_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE refresh_tokens SET session_started_at = created_at;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by VARCHAR(512) DEFAULT NULL;
+ALTER TABLE refresh_tokens ADD COLUMN reuse_detected_at TIMESTAMPTZ DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE refresh_tokens DROP COLUMN reuse_detected_at;
+ALTER TABLE refresh_tokens DROP COLUMN replaced_by;
+ALTER TABLE refresh_tokens DROP COLUMN session_started_at;
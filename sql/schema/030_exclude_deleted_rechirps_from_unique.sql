@@ -0,0 +1,9 @@
+-- +goose Up
+-- A rechirp in the trash must not keep the user from rechirping again.
+DROP INDEX chirps_user_id_rechirp_of;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of ON chirps (user_id, rechirp_of)
+    WHERE kind = 'rechirp' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_of;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of ON chirps (user_id, rechirp_of) WHERE kind = 'rechirp';
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Each MFA challenge token is backed by a row, so that the number of codes
+-- tried against it can be limited and it can only be used once.
+CREATE TABLE mfa_challenges(
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    used_at TIMESTAMPTZ DEFAULT NULL
+);
+CREATE INDEX mfa_challenges_user_id_created_at ON mfa_challenges (user_id, created_at);
+CREATE INDEX mfa_challenges_expires_at ON mfa_challenges (expires_at);
+
+-- +goose Down
+DROP TABLE mfa_challenges;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX refresh_tokens_expires_at ON refresh_tokens (expires_at);
+CREATE INDEX refresh_tokens_revoked_at ON refresh_tokens (revoked_at) WHERE revoked_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX refresh_tokens_revoked_at;
+DROP INDEX refresh_tokens_expires_at;
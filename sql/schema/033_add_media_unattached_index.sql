@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX media_unattached_created_at ON media (created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP INDEX media_unattached_created_at;
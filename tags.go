@@ -0,0 +1,114 @@
+package main
+
+import (
+	"chirpy/internal/chirptext"
+	"chirpy/internal/database"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+	defaultTrendingTags   = 10
+	maxTrendingTags       = 50
+)
+
+func (ac *apiConfig) handlerListTagChirps(rw http.ResponseWriter, req *http.Request) {
+	tag := chirptext.NormalizeHashtag(req.PathValue("tag"))
+	if tag == "" {
+		respondWithError(rw, http.StatusBadRequest, "Tag not specified", nil)
+		return
+	}
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirps []database.Chirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		chirps, err = ac.dbQueries.ListTagChirpsAsc(req.Context(), database.ListTagChirpsAscParams{
+			Tag:             tag,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		chirps, err = ac.dbQueries.ListTagChirpsDesc(req.Context(), database.ListTagChirpsDescParams{
+			Tag:             tag,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+
+	chirps, links := paginate(page, chirps, chirpCursor)
+	response, err := ac.buildChirpInfos(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerTrendingTags ranks the tags by the number of chirps using them
+// within the trailing time window given by the `window` query parameter.
+func (ac *apiConfig) handlerTrendingTags(rw http.ResponseWriter, req *http.Request) {
+	type trendingTag struct {
+		Tag        string `json:"tag"`
+		ChirpCount int64  `json:"chirp_count"`
+	}
+
+	window := defaultTrendingWindow
+	if windowString := req.URL.Query().Get("window"); windowString != "" {
+		var err error
+		window, err = time.ParseDuration(windowString)
+		if err != nil || window <= 0 || window > maxTrendingWindow {
+			respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid window '%s'. Must be a duration up to %s", windowString, maxTrendingWindow), err)
+			return
+		}
+	}
+	limit := defaultTrendingTags
+	if limitString := req.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxTrendingTags {
+			respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid limit '%s'. Must be between 1 and %d", limitString, maxTrendingTags), err)
+			return
+		}
+	}
+
+	tags, err := ac.dbQueries.ListTrendingTags(req.Context(), database.ListTrendingTagsParams{
+		Since:   time.Now().UTC().Add(-window),
+		MaxTags: int32(limit),
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get trending tags", err)
+		return
+	}
+
+	response := make([]trendingTag, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, trendingTag{
+			Tag:        tag.Name,
+			ChirpCount: tag.ChirpCount,
+		})
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
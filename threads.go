@@ -0,0 +1,108 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxThreadDepth   = 32
+	maxThreadReplies = 500
+)
+
+type chirpThreadNode struct {
+	chirpInfo
+	Replies []*chirpThreadNode `json:"replies"`
+}
+
+type chirpThread struct {
+	Ancestors []chirpInfo      `json:"ancestors"`
+	Chirp     *chirpThreadNode `json:"chirp"`
+}
+
+func (ac *apiConfig) handlerGetChirpThread(rw http.ResponseWriter, req *http.Request) {
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Chirp ID is invalid", err)
+		return
+	}
+	viewerID, err := ac.getOptionalUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	chirp, err := ac.findVisibleChirp(req.Context(), viewerID, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found", chirpID), err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirp", err)
+		return
+	}
+
+	ancestors, err := ac.dbQueries.ListChirpAncestors(req.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get thread", err)
+		return
+	}
+	descendants, err := ac.dbQueries.ListChirpDescendants(req.Context(), database.ListChirpDescendantsParams{
+		ChirpID:   chirp.ID,
+		MaxDepth:  maxThreadDepth,
+		MaxChirps: maxThreadReplies,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get thread", err)
+		return
+	}
+
+	// Replies below a hidden chirp are left out along with it, as
+	// nestReplies drops the chirps whose parent is missing.
+	hidden, err := ac.hiddenUserIDs(req.Context(), viewerID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get thread", err)
+		return
+	}
+	ancestors = slices.DeleteFunc(ancestors, func(c database.Chirp) bool { return hidden[c.UserID] })
+	descendants = slices.DeleteFunc(descendants, func(c database.Chirp) bool { return hidden[c.UserID] })
+
+	chirps := make([]database.Chirp, 0, len(ancestors)+1+len(descendants))
+	chirps = append(chirps, ancestors...)
+	chirps = append(chirps, chirp)
+	chirps = append(chirps, descendants...)
+	infos, err := ac.buildChirpInfos(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get thread", err)
+		return
+	}
+
+	respondWithJSON(rw, http.StatusOK, chirpThread{
+		Ancestors: infos[:len(ancestors)],
+		Chirp:     nestReplies(infos[len(ancestors)], infos[len(ancestors)+1:]),
+	})
+}
+
+// nestReplies arranges the descendants of root into a reply tree. The
+// descendants are expected in creation order, which is kept for siblings.
+func nestReplies(root chirpInfo, descendants []chirpInfo) *chirpThreadNode {
+	rootNode := &chirpThreadNode{chirpInfo: root, Replies: []*chirpThreadNode{}}
+	nodes := map[uuid.UUID]*chirpThreadNode{root.ID: rootNode}
+	for _, info := range descendants {
+		nodes[info.ID] = &chirpThreadNode{chirpInfo: info, Replies: []*chirpThreadNode{}}
+	}
+	for _, info := range descendants {
+		parent, ok := nodes[*info.InReplyTo]
+		if !ok {
+			continue
+		}
+		parent.Replies = append(parent.Replies, nodes[info.ID])
+	}
+	return rootNode
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	totpIssuer        = "Chirpy"
+	recoveryCodeCount = 10
+	mfaChallengeTTL   = 5 * time.Minute
+	// maxMFAChallengeAttempts is how many codes can be tried against a
+	// challenge token. maxMFAFailures bounds the failed codes of a user
+	// across all challenges within mfaFailureWindow, so that logging in
+	// again does not give unlimited tries.
+	maxMFAChallengeAttempts   = 5
+	maxMFAFailures            = 10
+	mfaFailureWindow          = 15 * time.Minute
+	mfaChallengePurgeInterval = time.Hour
+)
+
+var (
+	errMFANotEnabled       = errors.New("two-factor authentication not enabled")
+	errMFAAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	errInvalidSecondFactor = errors.New("invalid second factor")
+	errMFAChallengeUsed    = errors.New("MFA challenge already used")
+)
+
+// mfaChallenge is the response to the first step of the login of a user
+// with two-factor authentication.
+type mfaChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// issueMFAChallenge starts the second step of the login of a user and
+// returns the challenge token to send back with a code.
+func (ac *apiConfig) issueMFAChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	challenge, err := ac.dbQueries.CreateMFAChallenge(ctx, database.CreateMFAChallengeParams{
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return auth.MakeMFAChallengeJWT(userID, challenge.ID, ac.tokenSecret, mfaChallengeTTL)
+}
+
+// isMFAThrottled reports whether the user failed too many second factors
+// recently to be allowed to try again.
+func (ac *apiConfig) isMFAThrottled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	failures, err := ac.dbQueries.CountRecentMFAFailures(ctx, database.CountRecentMFAFailuresParams{
+		UserID: userID,
+		Since:  time.Now().UTC().Add(-mfaFailureWindow),
+	})
+	if err != nil {
+		return false, err
+	}
+	return failures >= maxMFAFailures, nil
+}
+
+// purgeExpiredMFAChallenges deletes the challenges that no longer count
+// towards the failures of their user.
+func (ac *apiConfig) purgeExpiredMFAChallenges(ctx context.Context) {
+	purged, err := ac.dbQueries.DeleteExpiredMFAChallenges(ctx, time.Now().UTC().Add(-mfaFailureWindow))
+	if err != nil {
+		log.Printf("Failed to purge expired MFA challenges: %s\n", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Purged %d expired MFA challenges\n", purged)
+	}
+}
+
+// verifySecondFactor checks a TOTP code or an unused recovery code of the
+// user, consuming it so it cannot be used again.
+func verifySecondFactor(ctx context.Context, qtx *database.Queries, userID uuid.UUID, code string) error {
+	credential, err := qtx.FindTOTPCredentialForUpdate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errMFANotEnabled
+		}
+		return err
+	}
+	if !credential.ConfirmedAt.Valid {
+		return errMFANotEnabled
+	}
+
+	if len(code) == auth.TOTPDigits {
+		counter, ok := auth.ValidateTOTPCode(credential.Secret, code, time.Now())
+		if !ok || counter <= credential.LastUsedCounter {
+			return errInvalidSecondFactor
+		}
+		return qtx.UpdateTOTPLastUsedCounter(ctx, database.UpdateTOTPLastUsedCounterParams{
+			UserID:          userID,
+			LastUsedCounter: counter,
+		})
+	}
+
+	// Recovery codes are hashed with a salt, so each unused one is checked.
+	codeHashes, err := qtx.ListUnusedRecoveryCodeHashes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, codeHash := range codeHashes {
+		if !auth.CheckRecoveryCode(code, codeHash) {
+			continue
+		}
+		used, err := qtx.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: codeHash,
+		})
+		if err != nil {
+			return err
+		}
+		if used == 0 {
+			return errInvalidSecondFactor
+		}
+		return nil
+	}
+	return errInvalidSecondFactor
+}
+
+// handlerStartTOTPEnrollment generates a TOTP secret for the authenticated
+// user. Two-factor authentication is only enabled once a code generated
+// from the secret is confirmed.
+func (ac *apiConfig) handlerStartTOTPEnrollment(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Password string `json:"password"`
+	}
+	type resData struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if !checkCurrentPassword(rw, user, params.Password) {
+		return
+	}
+
+	secret, err := auth.MakeTOTPSecret()
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	started, err := ac.dbQueries.UpsertTOTPCredential(req.Context(), database.UpsertTOTPCredentialParams{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to start enrollment", err)
+		return
+	}
+	if started == 0 {
+		respondWithError(rw, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	respondWithJSON(rw, http.StatusCreated, resData{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// handlerConfirmTOTPEnrollment enables two-factor authentication once the
+// user proves their authenticator app generates valid codes, and responds
+// with recovery codes. They are only shown this once.
+func (ac *apiConfig) handlerConfirmTOTPEnrollment(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Code string `json:"code"`
+	}
+	type resData struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	recoveryCodes, err := auth.MakeRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	// Hashing is slow, so it is done before locking the credential.
+	codeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		codeHash, err := auth.HashRecoveryCode(code)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+			return
+		}
+		codeHashes = append(codeHashes, codeHash)
+	}
+
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		credential, err := qtx.FindTOTPCredentialForUpdate(req.Context(), userID)
+		if err != nil {
+			return err
+		}
+		if credential.ConfirmedAt.Valid {
+			return errMFAAlreadyEnabled
+		}
+		counter, ok := auth.ValidateTOTPCode(credential.Secret, params.Code, time.Now())
+		if !ok {
+			return errInvalidSecondFactor
+		}
+		err = qtx.ConfirmTOTPCredential(req.Context(), database.ConfirmTOTPCredentialParams{
+			UserID:          userID,
+			LastUsedCounter: counter,
+		})
+		if err != nil {
+			return err
+		}
+		if err := qtx.DeleteRecoveryCodes(req.Context(), userID); err != nil {
+			return err
+		}
+		for _, codeHash := range codeHashes {
+			err := qtx.CreateRecoveryCode(req.Context(), database.CreateRecoveryCodeParams{
+				UserID:   userID,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondWithError(rw, http.StatusNotFound, "No two-factor enrollment in progress", err)
+		case errors.Is(err, errMFAAlreadyEnabled):
+			respondWithError(rw, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		case errors.Is(err, errInvalidSecondFactor):
+			respondWithError(rw, http.StatusBadRequest, "Invalid code", nil)
+		default:
+			respondWithError(rw, http.StatusInternalServerError, "Failed to confirm enrollment", err)
+		}
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, resData{RecoveryCodes: recoveryCodes})
+}
+
+// handlerDisableTOTP turns two-factor authentication off. It takes both
+// the password and a code, which can be a recovery code.
+func (ac *apiConfig) handlerDisableTOTP(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+	if !checkCurrentPassword(rw, user, params.Password) {
+		return
+	}
+
+	err := ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		if err := verifySecondFactor(req.Context(), qtx, user.ID, params.Code); err != nil {
+			return err
+		}
+		if err := qtx.DeleteRecoveryCodes(req.Context(), user.ID); err != nil {
+			return err
+		}
+		return qtx.DeleteTOTPCredential(req.Context(), user.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errMFANotEnabled):
+			respondWithError(rw, http.StatusNotFound, "Two-factor authentication is not enabled", nil)
+		case errors.Is(err, errInvalidSecondFactor):
+			respondWithError(rw, http.StatusForbidden, "Invalid code", nil)
+		default:
+			respondWithError(rw, http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+		}
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handlerLoginMFA completes the login of a user with two-factor
+// authentication, given the challenge token from POST /api/login and a
+// TOTP or recovery code. A challenge token can only be tried a few times
+// and is used up by a successful login.
+func (ac *apiConfig) handlerLoginMFA(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid body", err)
+		return
+	}
+	userID, challengeID, err := auth.ValidateMFAChallengeJWT(params.MFAToken, ac.tokenSecret)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	throttled, err := ac.isMFAThrottled(req.Context(), userID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if throttled {
+		respondWithError(rw, http.StatusTooManyRequests, "Too many failed attempts, try again later", nil)
+		return
+	}
+	// The attempt is counted before the code is checked, and outside of the
+	// transaction so that failures are recorded too.
+	counted, err := ac.dbQueries.UseMFAChallengeAttempt(req.Context(), database.UseMFAChallengeAttemptParams{
+		ID:          challengeID,
+		UserID:      userID,
+		MaxAttempts: maxMFAChallengeAttempts,
+	})
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if counted == 0 {
+		respondWithError(rw, http.StatusUnauthorized, "MFA challenge expired, log in again", nil)
+		return
+	}
+
+	var user database.User
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		if err := verifySecondFactor(req.Context(), qtx, userID, params.Code); err != nil {
+			return err
+		}
+		completed, err := qtx.CompleteMFAChallenge(req.Context(), challengeID)
+		if err != nil {
+			return err
+		}
+		if completed == 0 {
+			return errMFAChallengeUsed
+		}
+		user, err = qtx.FindUserById(req.Context(), userID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidSecondFactor) || errors.Is(err, errMFANotEnabled) || errors.Is(err, errMFAChallengeUsed) || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	ac.respondWithLoginTokens(rw, req, user)
+}
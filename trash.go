@@ -0,0 +1,189 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// trashRetention is how long deleted chirps can be restored before they
+	// are purged for good.
+	trashRetention     = 30 * 24 * time.Hour
+	trashPurgeInterval = time.Hour
+	trashPurgeBatch    = 500
+)
+
+var (
+	errTrashExpired   = errors.New("chirp retention expired")
+	errAlreadyRechirp = errors.New("chirp already rechirped")
+)
+
+func trashCutoff() sql.NullTime {
+	return sql.NullTime{Time: time.Now().Add(-trashRetention), Valid: true}
+}
+
+// handlerListTrash lists the authenticated user's deleted chirps that can
+// still be restored.
+func (ac *apiConfig) handlerListTrash(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	page, err := parsePageRequest(req.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var chirps []database.Chirp
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	if page.ascending() {
+		chirps, err = ac.dbQueries.ListTrashAsc(req.Context(), database.ListTrashAscParams{
+			UserID:          userID,
+			DeletedSince:    trashCutoff(),
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	} else {
+		chirps, err = ac.dbQueries.ListTrashDesc(req.Context(), database.ListTrashDescParams{
+			UserID:          userID,
+			DeletedSince:    trashCutoff(),
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+
+	chirps, links := paginate(page, chirps, chirpCursor)
+	response, err := ac.buildChirpInfos(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to get chirps", err)
+		return
+	}
+	for i, chirp := range chirps {
+		response[i].DeletedAt = &chirp.DeletedAt.Time
+	}
+	setPaginationLinks(rw, req, links)
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// handlerRestoreChirp takes a chirp owned by the authenticated user out of
+// the trash, along with the plain rechirps deleted with it.
+func (ac *apiConfig) handlerRestoreChirp(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	chirpID, err := getUUIDPathValue(req, "id")
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	var chirp database.Chirp
+	err = ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		deleted, err := qtx.FindDeletedChirpByIdForUpdate(req.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if deleted.UserID != userID {
+			return errForbidden
+		}
+		if !deleted.DeletedAt.Time.After(trashCutoff().Time) {
+			return errTrashExpired
+		}
+		// A plain rechirp cannot outlive the chirp it shares.
+		if deleted.Kind == chirpKindRechirp && deleted.RechirpOf.Valid {
+			if _, err := qtx.FindChirpById(req.Context(), deleted.RechirpOf.UUID); err != nil {
+				return err
+			}
+		}
+		err = qtx.RestoreChirp(req.Context(), database.RestoreChirpParams{
+			ID:        deleted.ID,
+			DeletedAt: deleted.DeletedAt,
+		})
+		if err != nil {
+			// The user rechirped the same chirp again since deleting this one.
+			if isUniqueViolation(err) {
+				return errAlreadyRechirp
+			}
+			return err
+		}
+		chirp, err = qtx.FindChirpById(req.Context(), deleted.ID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondWithError(rw, http.StatusNotFound, fmt.Sprintf("Chirp %s not found in trash", chirpID), err)
+		case errors.Is(err, errForbidden):
+			respondWithError(rw, http.StatusForbidden, "Access Forbiden", nil)
+		case errors.Is(err, errTrashExpired):
+			respondWithError(rw, http.StatusGone, fmt.Sprintf("Chirp %s can no longer be restored", chirpID), nil)
+		case errors.Is(err, errAlreadyRechirp):
+			respondWithError(rw, http.StatusConflict, "Chirp already rechirped", nil)
+		default:
+			respondWithError(rw, http.StatusInternalServerError, "Failed to restore chirp", err)
+		}
+		return
+	}
+
+	response, err := ac.buildChirpInfo(req.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// purgeExpiredTrash permanently deletes the chirps that have been in the
+// trash for longer than the retention period, along with their media.
+func (ac *apiConfig) purgeExpiredTrash(ctx context.Context) {
+	for {
+		var chirpIDs []uuid.UUID
+		var storageKeys []string
+		err := ac.withTx(ctx, func(qtx *database.Queries) error {
+			var err error
+			chirpIDs, err = qtx.ListPurgeableChirpIDs(ctx, database.ListPurgeableChirpIDsParams{
+				DeletedBefore: trashCutoff(),
+				MaxChirps:     trashPurgeBatch,
+			})
+			if err != nil || len(chirpIDs) == 0 {
+				return err
+			}
+			storageKeys, err = qtx.DeleteMediaForChirps(ctx, chirpIDs)
+			if err != nil {
+				return err
+			}
+			_, err = qtx.DeleteChirpsByIds(ctx, chirpIDs)
+			return err
+		})
+		if err != nil {
+			log.Printf("Failed to purge deleted chirps: %s\n", err)
+			return
+		}
+		// The files are only removed once the rows referencing them are gone.
+		ac.deleteStoredMedia(ctx, storageKeys)
+		if len(chirpIDs) > 0 {
+			log.Printf("Purged %d deleted chirps\n", len(chirpIDs))
+		}
+		if len(chirpIDs) < trashPurgeBatch {
+			return
+		}
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"chirpy/internal/auth"
+	"chirpy/internal/chirptext"
 	"chirpy/internal/database"
+	"chirpy/internal/mailer"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
@@ -15,19 +18,97 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Token        string    `json:"token"`
-	RefreshToken string    `json:"refresh_token"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Token         string    `json:"token"`
+	RefreshToken  string    `json:"refresh_token"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Handle        *string   `json:"handle"`
+	DisplayName   *string   `json:"display_name"`
+	Bio           *string   `json:"bio"`
+	AvatarURL     *string   `json:"avatar_url"`
+	EmailVerified bool      `json:"email_verified"`
+}
+
+// newUser returns the private representation of a user, only meant for
+// the user themselves.
+func newUser(user database.User) User {
+	return User{
+		ID:            user.ID,
+		Email:         user.Email,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		IsChirpyRed:   user.IsChirpyRed,
+		Handle:        nullStringPtr(user.Handle),
+		DisplayName:   nullStringPtr(user.DisplayName),
+		Bio:           nullStringPtr(user.Bio),
+		AvatarURL:     nullStringPtr(user.AvatarUrl),
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// parseHandle validates the optional handle sent by a client and returns
+// its normalized form.
+func parseHandle(handle *string) (sql.NullString, error) {
+	if handle == nil {
+		return sql.NullString{}, nil
+	}
+	normalized := chirptext.NormalizeHandle(*handle)
+	if !chirptext.IsValidHandle(normalized) {
+		return sql.NullString{}, errors.New("Invalid handle. Must be up to 30 letters, digits or underscores")
+	}
+	// "me" would clash with the /api/users/me endpoints.
+	if normalized == "me" {
+		return sql.NullString{}, errors.New("Handle 'me' is reserved")
+	}
+	return sql.NullString{String: normalized, Valid: true}, nil
+}
+
+// getAuthenticatedUserID returns the ID of the user owning the access token
+// sent in the Authorization header.
+func (ac *apiConfig) getAuthenticatedUserID(req *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.ValidateJWT(token, ac.tokenSecret)
+}
+
+// getOptionalUserID is like getAuthenticatedUserID for endpoints that can
+// also be used anonymously. It only fails if a token is sent but invalid.
+func (ac *apiConfig) getOptionalUserID(req *http.Request) (uuid.NullUUID, error) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+func getUUIDPathValue(req *http.Request, name string) (uuid.UUID, error) {
+	value := req.PathValue(name)
+	if value == "" {
+		return uuid.Nil, fmt.Errorf("path value '%s' not specified", name)
+	}
+	return uuid.Parse(value)
 }
 
 func (ac *apiConfig) handlerCreateUser(rw http.ResponseWriter, req *http.Request) {
 	type reqData struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string  `json:"email"`
+		Password string  `json:"password"`
+		Handle   *string `json:"handle"`
 	}
 
 	body := reqData{}
@@ -37,6 +118,19 @@ func (ac *apiConfig) handlerCreateUser(rw http.ResponseWriter, req *http.Request
 		respondWithError(rw, http.StatusBadRequest, "Failed to parse request body", err)
 		return
 	}
+	if !mailer.ValidAddress(body.Email) {
+		respondWithError(rw, http.StatusBadRequest, "Invalid email", nil)
+		return
+	}
+	if err := validatePassword(body.Password); err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	handle, err := parseHandle(body.Handle)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
 	hashedPWD, err := auth.HashPassword(body.Password)
 	if err != nil {
 		log.Printf("Failed to hash password: %s\n", err)
@@ -47,21 +141,26 @@ func (ac *apiConfig) handlerCreateUser(rw http.ResponseWriter, req *http.Request
 	user, err := ac.dbQueries.CreateUser(req.Context(), database.CreateUserParams{
 		Email:          body.Email,
 		HashedPassword: hashedPWD,
+		Handle:         handle,
 	})
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(rw, http.StatusConflict, "Email or handle already taken", err)
+			return
+		}
 		log.Printf("Failed to create user: %s", err)
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
 
-	respondWithJSON(rw, http.StatusCreated, User{
-		ID:          user.ID,
-		Email:       user.Email,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		IsChirpyRed: user.IsChirpyRed,
-	})
+	// The account exists at this point, so a failure to send the email is
+	// not reported to the client, who can ask for a new one.
+	if err := ac.sendEmailVerification(req.Context(), user); err != nil {
+		log.Printf("Failed to send verification email to user %s: %s\n", user.ID, err)
+	}
+
+	respondWithJSON(rw, http.StatusCreated, newUser(user))
 }
 
 func (ac *apiConfig) handlerLogin(rw http.ResponseWriter, req *http.Request) {
@@ -94,6 +193,38 @@ func (ac *apiConfig) handlerLogin(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Users with two-factor authentication get a challenge token to send
+	// along with their code instead of the access token.
+	mfaEnabled, err := ac.dbQueries.IsTOTPEnabled(req.Context(), user.ID)
+	if err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	if mfaEnabled {
+		throttled, err := ac.isMFAThrottled(req.Context(), user.ID)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+			return
+		}
+		if throttled {
+			respondWithError(rw, http.StatusTooManyRequests, "Too many failed attempts, try again later", nil)
+			return
+		}
+		challenge, err := ac.issueMFAChallenge(req.Context(), user.ID)
+		if err != nil {
+			respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+			return
+		}
+		respondWithJSON(rw, http.StatusOK, mfaChallenge{MFARequired: true, MFAToken: challenge})
+		return
+	}
+
+	ac.respondWithLoginTokens(rw, req, user)
+}
+
+// respondWithLoginTokens completes the login of a user by issuing them an
+// access token and a refresh token.
+func (ac *apiConfig) respondWithLoginTokens(rw http.ResponseWriter, req *http.Request, user database.User) {
 	token, err := auth.MakeJWT(user.ID, ac.tokenSecret, 1*time.Hour)
 	if err != nil {
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
@@ -105,44 +236,44 @@ func (ac *apiConfig) handlerLogin(rw http.ResponseWriter, req *http.Request) {
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
+	userAgent, ipAddress := sessionMetadata(req)
 	_, err = ac.dbQueries.CreateRefreshToken(req.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
+		TokenHash: auth.HashToken(refreshToken),
 		UserID:    user.ID,
 		ExpiresAt: time.Now().UTC().Add(60 * 24 * time.Hour),
+		SessionID: uuid.New(),
+		UserAgent: userAgent,
+		IpAddress: ipAddress,
 	})
 	if err != nil {
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
 
-	respondWithJSON(rw, http.StatusOK, User{
-		ID:           user.ID,
-		Email:        user.Email,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Token:        token,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  user.IsChirpyRed,
-	})
+	response := newUser(user)
+	response.Token = token
+	response.RefreshToken = refreshToken
+	respondWithJSON(rw, http.StatusOK, response)
 }
 
+// handlerRefreshToken responds with a new access token and a new refresh
+// token. The refresh token presented cannot be used again.
 func (ac *apiConfig) handlerRefreshToken(rw http.ResponseWriter, req *http.Request) {
 	refreshToken, err := auth.GetBearerToken(req.Header)
 	if err != nil {
 		respondWithError(rw, http.StatusUnauthorized, "Must provide refresh token", err)
 		return
 	}
-	refreshTokenInfo, err := ac.dbQueries.FindRefreshToken(req.Context(), refreshToken)
+	refreshTokenInfo, newRefreshToken, err := ac.rotateRefreshToken(req, refreshToken)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		switch {
+		case errors.Is(err, sql.ErrNoRows), errors.Is(err, errRefreshTokenReused):
 			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
-			return
+		case errors.Is(err, errRefreshTokenExpired):
+			respondWithError(rw, http.StatusUnauthorized, "Refresh token expired", nil)
+		default:
+			respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		}
-		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
-		return
-	}
-	if refreshTokenInfo.ExpiresAt.UTC().Before(time.Now().UTC()) {
-		respondWithError(rw, http.StatusUnauthorized, "Refresh token expired", nil)
 		return
 	}
 
@@ -153,10 +284,12 @@ func (ac *apiConfig) handlerRefreshToken(rw http.ResponseWriter, req *http.Reque
 	}
 
 	type responseData struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 	respondWithJSON(rw, http.StatusOK, responseData{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
 	})
 }
 
@@ -166,7 +299,8 @@ func (ac *apiConfig) handlerRevokeRefreshToken(rw http.ResponseWriter, req *http
 		respondWithError(rw, http.StatusUnauthorized, "Must provide refresh token", err)
 		return
 	}
-	_, err = ac.dbQueries.FindRefreshToken(req.Context(), refreshToken)
+	tokenHash := auth.HashToken(refreshToken)
+	_, err = ac.dbQueries.FindRefreshToken(req.Context(), tokenHash)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
@@ -175,7 +309,7 @@ func (ac *apiConfig) handlerRevokeRefreshToken(rw http.ResponseWriter, req *http
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
-	err = ac.dbQueries.RevokeRefreshToken(req.Context(), refreshToken)
+	err = ac.dbQueries.RevokeRefreshToken(req.Context(), tokenHash)
 	if err != nil {
 		respondWithError(rw, http.StatusUnauthorized, "Internal Server Error", err)
 		return
@@ -184,63 +318,95 @@ func (ac *apiConfig) handlerRevokeRefreshToken(rw http.ResponseWriter, req *http
 	rw.WriteHeader(http.StatusNoContent)
 }
 
+// handlerUpdateUser applies a partial update to the profile of the
+// authenticated user. Omitted fields are left unchanged and fields set to
+// an empty string are cleared. Credentials have dedicated endpoints.
 func (ac *apiConfig) handlerUpdateUser(rw http.ResponseWriter, req *http.Request) {
-	accessToken, err := auth.GetBearerToken(req.Header)
+	type reqData struct {
+		Email       *string `json:"email"`
+		Password    *string `json:"password"`
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
+	}
+
+	userID, err := ac.getAuthenticatedUserID(req)
 	if err != nil {
 		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(accessToken, ac.tokenSecret)
+	decoder := json.NewDecoder(req.Body)
+	var data = reqData{}
+	if err = decoder.Decode(&data); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+
+	if data.Email != nil {
+		respondWithError(rw, http.StatusBadRequest, "Use POST /api/users/me/email to change the email", nil)
+		return
+	}
+	if data.Password != nil {
+		respondWithError(rw, http.StatusBadRequest, "Use POST /api/users/me/password to change the password", nil)
+		return
+	}
+
+	params := database.UpdateUserProfileParams{ID: userID}
+	params.Handle, err = parseHandle(data.Handle)
 	if err != nil {
-		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	user, err := ac.dbQueries.FindUserById(req.Context(), userID)
+	params.DisplayName, err = parseProfileText(data.DisplayName, "Display name", maxDisplayNameLength)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
-			return
-		}
-		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-
-	type reqData struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	params.Bio, err = parseProfileText(data.Bio, "Bio", maxBioLength)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
-
-	decoder := json.NewDecoder(req.Body)
-	var data = reqData{}
-	if err = decoder.Decode(&data); err != nil {
-		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+	params.AvatarUrl, err = parseAvatarURL(data.AvatarURL)
+	if err != nil {
+		respondWithError(rw, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(data.Password)
+	user, err := ac.dbQueries.UpdateUserProfile(req.Context(), params)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+			return
+		}
+		if isUniqueViolation(err) {
+			respondWithError(rw, http.StatusConflict, "Handle already taken", err)
+			return
+		}
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
+	respondWithJSON(rw, http.StatusOK, newUser(user))
+}
 
-	updatedAt, err := ac.dbQueries.UpdateUserCredentials(req.Context(), database.UpdateUserCredentialsParams{
-		Email:          data.Email,
-		HashedPassword: hashedPassword,
-		ID:             user.ID,
-	})
-
+// handlerGetCurrentUser returns the private representation of the
+// authenticated user.
+func (ac *apiConfig) handlerGetCurrentUser(rw http.ResponseWriter, req *http.Request) {
+	userID, err := ac.getAuthenticatedUserID(req)
+	if err != nil {
+		respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	user, err := ac.dbQueries.FindUserById(req.Context(), userID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusUnauthorized, "Invalid credentials", err)
+			return
+		}
 		respondWithError(rw, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
-
-	respondWithJSON(rw, http.StatusOK, User{
-		ID:          user.ID,
-		Email:       data.Email,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   updatedAt,
-		IsChirpyRed: user.IsChirpyRed,
-	})
-
+	respondWithJSON(rw, http.StatusOK, newUser(user))
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/mailer"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	tokenPurposeEmailVerification = "email_verification"
+	emailVerificationTokenTTL     = 48 * time.Hour
+)
+
+// newMailer returns the mailer configured through the environment. Emails
+// are logged unless another backend is selected. The file backend writes
+// them to MAIL_DIR, which defaults to a directory below the temporary
+// directory so that they are never served by /app/.
+func newMailer() (mailer.Mailer, error) {
+	switch backend := os.Getenv("MAILER"); backend {
+	case "", "log":
+		return mailer.NewLogMailer(log.Default()), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "chirpy-mail")
+		}
+		return mailer.NewFileMailer(dir), nil
+	case "smtp":
+		config := mailer.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+		if config.Port == "" {
+			config.Port = "587"
+		}
+		if config.Host == "" || !mailer.ValidAddress(config.From) {
+			return nil, errors.New("SMTP_HOST and MAIL_FROM envvars must be set")
+		}
+		return mailer.NewSMTPMailer(config), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer '%s'", backend)
+	}
+}
+
+// issueAccountToken creates a single use token for the given purpose,
+// invalidating the tokens previously issued to the user for it.
+func (ac *apiConfig) issueAccountToken(ctx context.Context, userID uuid.UUID, purpose, email string, ttl time.Duration) (string, error) {
+	token, err := auth.MakeToken()
+	if err != nil {
+		return "", err
+	}
+	err = ac.withTx(ctx, func(qtx *database.Queries) error {
+		err := qtx.InvalidateAccountTokens(ctx, database.InvalidateAccountTokensParams{
+			UserID:  userID,
+			Purpose: purpose,
+		})
+		if err != nil {
+			return err
+		}
+		return qtx.CreateAccountToken(ctx, database.CreateAccountTokenParams{
+			TokenHash: auth.HashToken(token),
+			UserID:    userID,
+			Purpose:   purpose,
+			Email:     email,
+			ExpiresAt: time.Now().UTC().Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (ac *apiConfig) sendEmailVerification(ctx context.Context, user database.User) error {
+	token, err := ac.issueAccountToken(ctx, user.ID, tokenPurposeEmailVerification, user.Email, emailVerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+	return ac.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email",
+		Body: fmt.Sprintf("Verify this address for your Chirpy account by sending the following token to POST /api/users/verify:\n\n%s\n\nThe token expires in %s.",
+			token, emailVerificationTokenTTL),
+	})
+}
+
+// handlerVerifyEmail marks the email matching the token as verified. The
+// token alone authenticates the request.
+func (ac *apiConfig) handlerVerifyEmail(rw http.ResponseWriter, req *http.Request) {
+	type reqData struct {
+		Token string `json:"token"`
+	}
+
+	params := reqData{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(rw, http.StatusBadRequest, "Invalid data", err)
+		return
+	}
+
+	var user database.User
+	err := ac.withTx(req.Context(), func(qtx *database.Queries) error {
+		token, err := qtx.ConsumeAccountToken(req.Context(), database.ConsumeAccountTokenParams{
+			TokenHash: auth.HashToken(params.Token),
+			Purpose:   tokenPurposeEmailVerification,
+		})
+		if err != nil {
+			return err
+		}
+		user, err = qtx.VerifyUserEmail(req.Context(), database.VerifyUserEmailParams{
+			ID:    token.UserID,
+			Email: token.Email,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(rw, http.StatusBadRequest, "Invalid or expired token", err)
+			return
+		}
+		respondWithError(rw, http.StatusInternalServerError, "Failed to verify email", err)
+		return
+	}
+	respondWithJSON(rw, http.StatusOK, newUser(user))
+}
+
+// handlerResendEmailVerification sends a new verification token to the
+// authenticated user, invalidating the previous ones.
+func (ac *apiConfig) handlerResendEmailVerification(rw http.ResponseWriter, req *http.Request) {
+	user, ok := ac.getAuthenticatedUser(rw, req)
+	if !ok {
+		return
+	}
+	if user.EmailVerified {
+		respondWithError(rw, http.StatusConflict, "Email already verified", nil)
+		return
+	}
+	if err := ac.sendEmailVerification(req.Context(), user); err != nil {
+		respondWithError(rw, http.StatusInternalServerError, "Failed to send verification email", err)
+		return
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}